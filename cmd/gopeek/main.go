@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/nouuu/gopeek"
 	"github.com/nouuu/gopeek/internal/logger"
-	"github.com/nouuu/gopeek/internal/scanner"
+	"github.com/nouuu/gopeek/internal/progress"
 	"github.com/spf13/cobra"
 )
 
+// stdoutOutput is the output file name that writes the document to stdout.
+const stdoutOutput = "-"
+
 var (
 	version  = "dev"
 	commit   = "none"
@@ -19,29 +29,294 @@ var (
 )
 
 var rootCmd = &cobra.Command{
-	Use:     "gopeek [path]",
-	Example: "gopeek ./",
+	Use:     "gopeek [path...]",
+	Example: "gopeek ./\ngopeek ./api ./web ./shared/proto",
 	Short:   "Scan a project directory and output its structure and content",
 	Version: formatVersion(),
-	Args:    cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		log := logger.Default()
-		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
-			log = log.WithLevel(slog.LevelDebug)
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    run,
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	outputFile, _ := cmd.Flags().GetString("output")
+	toStdout := outputFile == stdoutOutput
+
+	log := newLogger(cmd, toStdout)
+	opts, err := scanOptions(cmd, log)
+	if err != nil {
+		return err
+	}
+	if !toStdout {
+		opts.Output = outputFile
+		log.Debug("output file", "file", outputFile)
+	}
+
+	var failOn []gopeek.ErrorKind
+	kinds, _ := cmd.Flags().GetStringSlice("fail-on")
+	for _, value := range kinds {
+		kind, err := gopeek.ParseErrorKind(value)
+		if err != nil {
+			return err
 		}
+		failOn = append(failOn, kind)
+	}
+
+	markdown, err := renderOptions(cmd)
+	if err != nil {
+		return err
+	}
 
-		cfg := scanner.DefaultConfig()
-		if ignore, _ := cmd.Flags().GetStringSlice("ignore"); len(ignore) > 0 {
-			cfg.IgnorePatterns = ignore
-			log.Debug("ignore patterns", "patterns", ignore)
+	split, err := splitSize(cmd)
+	if err != nil {
+		return err
+	}
+	if split.Limit > 0 && toStdout {
+		return errors.New("--split-size needs an output file, not stdout")
+	}
+
+	ctx := cmd.Context()
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var printer *progress.Printer
+	noProgress, _ := cmd.Flags().GetBool("no-progress")
+	if !noProgress && !toStdout && progress.IsTerminal(os.Stderr) {
+		printer = progress.New(os.Stderr)
+		opts.OnProgress = printer.Update
+		defer printer.Done()
+	}
+
+	snapshots := make([]*gopeek.Snapshot, 0, len(args))
+	for _, root := range args {
+		snapshot, err := gopeek.Scan(ctx, root, opts)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return fmt.Errorf("scan of %s timed out after %s", root, timeout)
+		case errors.Is(err, context.Canceled):
+			return fmt.Errorf("scan of %s interrupted", root)
+		case err != nil:
+			return fmt.Errorf("root %s: %w", root, err)
 		}
-		outputFile, _ := cmd.Flags().GetString("output")
-		cfg.Output = outputFile
-		log.Debug("output file", "file", outputFile)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := gopeek.CheckErrors(failOn, snapshots...); err != nil {
+		return err
+	}
+	if slice, ok := unmatchedSlice(opts.Slices, snapshots); ok {
+		return fmt.Errorf("--slice %s matches no scanned file", slice.Path)
+	}
+	// Slices were asked for explicitly, a file left out for one is a failure.
+	if err := gopeek.CheckErrors([]gopeek.ErrorKind{gopeek.ErrorSlice}, snapshots...); err != nil {
+		return err
+	}
 
-		s := scanner.New(args[0], cfg, log)
-		return s.Run()
-	},
+	var stats gopeek.Stats
+	for _, snapshot := range snapshots {
+		stats.Merge(snapshot.Stats)
+	}
+
+	if toStdout {
+		if err := markdown.Render(cmd.OutOrStdout(), snapshots...); err != nil {
+			return err
+		}
+	} else if err := writeOutput(log, markdown, outputFile, split, snapshots); err != nil {
+		return err
+	}
+	if printer != nil {
+		printer.Done()
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), stats.Summary())
+	return nil
+}
+
+var watchCmd = &cobra.Command{
+	Use:     "watch [path...]",
+	Example: "gopeek watch ./\ngopeek watch ./api ./web -o context.md --outline",
+	Short:   "Regenerate the output whenever files of the project change",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runWatch,
+}
+
+// runWatch writes the output once, then again after each batch of changes,
+// until interrupted.
+func runWatch(cmd *cobra.Command, args []string) error {
+	outputFile, _ := cmd.Flags().GetString("output")
+	if outputFile == stdoutOutput {
+		return errors.New("watch needs an output file, not stdout")
+	}
+	log := newLogger(cmd, false)
+
+	opts, err := scanOptions(cmd, log)
+	if err != nil {
+		return err
+	}
+	opts.Output = outputFile
+
+	markdown, err := renderOptions(cmd)
+	if err != nil {
+		return err
+	}
+	split, err := splitSize(cmd)
+	if err != nil {
+		return err
+	}
+
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+	if debounce <= 0 {
+		return fmt.Errorf("invalid debounce %s (expected more than 0)", debounce)
+	}
+
+	log.Info("watching for changes", "roots", args, "debounce", debounce)
+	return gopeek.Watch(cmd.Context(), args, opts, debounce, func(snapshots []*gopeek.Snapshot) error {
+		// The file may be created later on.
+		if slice, ok := unmatchedSlice(opts.Slices, snapshots); ok {
+			log.Warn("slice matches no scanned file", "path", slice.Path)
+		}
+		return writeOutput(log, markdown, outputFile, split, snapshots)
+	})
+}
+
+// unmatchedSlice returns the first of slices that applies to none of the
+// files of snapshots, most likely a mistyped path.
+func unmatchedSlice(slices []gopeek.Slice, snapshots []*gopeek.Snapshot) (gopeek.Slice, bool) {
+	for _, slice := range slices {
+		matched := false
+		for _, snapshot := range snapshots {
+			for _, file := range snapshot.Files {
+				matched = matched || slice.Matches(file.Path)
+			}
+			// Files left out because the slice cannot be resolved match it.
+			for _, err := range snapshot.Errors {
+				matched = matched || err.Kind == gopeek.ErrorSlice && slice.Matches(filepath.ToSlash(err.Path))
+			}
+		}
+		if !matched {
+			return slice, true
+		}
+	}
+	return gopeek.Slice{}, false
+}
+
+// newLogger returns the logger of the command, which writes to stderr when
+// stdout holds the document.
+func newLogger(cmd *cobra.Command, toStdout bool) *logger.Logger {
+	level := slog.LevelInfo
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		level = slog.LevelDebug
+	}
+	log := logger.Default()
+	switch {
+	case toStdout:
+		// Keep stdout for the document only.
+		log = logger.New(cmd.ErrOrStderr(), level)
+	case level == slog.LevelDebug:
+		log = log.WithLevel(level)
+	}
+	return log
+}
+
+// splitSize parses the --split-size flag, its limit is 0 when unset.
+func splitSize(cmd *cobra.Command) (gopeek.SplitSize, error) {
+	value, _ := cmd.Flags().GetString("split-size")
+	if value == "" {
+		return gopeek.SplitSize{}, nil
+	}
+	return gopeek.ParseSplitSize(value)
+}
+
+// writeOutput writes snapshots to the output file, or to its parts when
+// split has a limit.
+func writeOutput(log *logger.Logger, markdown gopeek.Markdown, outputFile string, split gopeek.SplitSize, snapshots []*gopeek.Snapshot) error {
+	if split.Limit > 0 {
+		log.Info("writing output parts", "file", outputFile, "limit", split.String())
+		paths, err := markdown.WriteParts(outputFile, split, snapshots...)
+		if err != nil {
+			return err
+		}
+		log.Debug("output parts written", "parts", paths)
+		return nil
+	}
+	log.Info("writing output", "file", outputFile)
+	return markdown.WriteFile(outputFile, snapshots...)
+}
+
+// scanOptions builds the scan options from the command flags.
+func scanOptions(cmd *cobra.Command, log *logger.Logger) (gopeek.Options, error) {
+	opts := gopeek.DefaultOptions()
+	opts.Logger = log.Logger
+	if ignore, _ := cmd.Flags().GetStringSlice("ignore"); len(ignore) > 0 {
+		opts.IgnorePatterns = ignore
+		log.Debug("ignore patterns", "patterns", ignore)
+	}
+	symlinks, _ := cmd.Flags().GetString("symlinks")
+	policy, err := gopeek.ParseSymlinkPolicy(symlinks)
+	if err != nil {
+		return opts, err
+	}
+	opts.Symlinks = policy
+
+	sortFlag, _ := cmd.Flags().GetString("sort")
+	order, err := gopeek.ParseSortOrder(sortFlag)
+	if err != nil {
+		return opts, err
+	}
+	opts.Sort = order
+
+	maxDepth, _ := cmd.Flags().GetInt("max-depth")
+	if maxDepth < 0 {
+		return opts, fmt.Errorf("invalid max depth %d (expected 0 or more)", maxDepth)
+	}
+	opts.MaxDepth = maxDepth
+
+	threshold, _ := cmd.Flags().GetInt("collapse")
+	if threshold < 0 {
+		return opts, fmt.Errorf("invalid collapse threshold %d (expected 0 or more)", threshold)
+	}
+	opts.CollapseThreshold = threshold
+	opts.ExpandPatterns, _ = cmd.Flags().GetStringSlice("expand")
+
+	values, _ := cmd.Flags().GetStringSlice("slice")
+	for _, value := range values {
+		slice, err := gopeek.ParseSlice(value)
+		if err != nil {
+			return opts, err
+		}
+		opts.Slices = append(opts.Slices, slice)
+	}
+
+	opts.Outline, _ = cmd.Flags().GetBool("outline")
+	opts.Minify, _ = cmd.Flags().GetBool("minify")
+	opts.Overview, _ = cmd.Flags().GetBool("overview")
+	opts.Dependencies, _ = cmd.Flags().GetBool("deps")
+
+	// The structure alone does not need the content of the files.
+	opts.SkipContent, _ = cmd.Flags().GetBool("tree-only")
+	return opts, nil
+}
+
+// renderOptions builds the Markdown rendering options from the command flags.
+func renderOptions(cmd *cobra.Command) (gopeek.Markdown, error) {
+	var markdown gopeek.Markdown
+	markdown.Summary, _ = cmd.Flags().GetBool("summary")
+	markdown.Sizes, _ = cmd.Flags().GetBool("sizes")
+	markdown.Lines, _ = cmd.Flags().GetBool("line-counts")
+	markdown.NoEmoji, _ = cmd.Flags().GetBool("no-emoji")
+	markdown.LineNumbers, _ = cmd.Flags().GetBool("line-numbers")
+	markdown.SkipContents, _ = cmd.Flags().GetBool("tree-only")
+	markdown.SkipStructure, _ = cmd.Flags().GetBool("content-only")
+
+	structure, _ := cmd.Flags().GetString("structure")
+	style, err := gopeek.ParseStructureStyle(structure)
+	if err != nil {
+		return markdown, err
+	}
+	markdown.Structure = style
+	return markdown, nil
 }
 
 func formatVersion() string {
@@ -56,17 +331,64 @@ func formatVersion() string {
 }
 
 func init() {
-	rootCmd.Flags().StringP("output", "o", "project_knowledge.md", "Output file")
-	rootCmd.Flags().StringSliceP("ignore", "i", []string{}, "Patterns to ignore")
-	rootCmd.Flags().Bool("verbose", false, "Verbose output")
+	for _, cmd := range []*cobra.Command{rootCmd, watchCmd} {
+		addOutputFlags(cmd)
+	}
+	rootCmd.Flags().Duration("timeout", 0, "Abort the scan after this duration (e.g. 30s, 5m), 0 for no limit")
+	rootCmd.Flags().StringSlice("fail-on", []string{}, "Fail without writing output on these errors: permission, too-large, binary, read, slice")
+	rootCmd.Flags().Bool("no-progress", false, "Disable the progress line shown on terminals")
+	watchCmd.Flags().Duration("debounce", 300*time.Millisecond, "Wait for files to stop changing this long before regenerating")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// addOutputFlags adds the flags controlling what is scanned and how it is
+// written, shared by the commands.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "project_knowledge.md", "Output file, - for stdout")
+	cmd.Flags().StringSliceP("ignore", "i", []string{}, "Patterns to ignore")
+	cmd.Flags().String("symlinks", string(gopeek.SymlinkList), "Symlink handling: skip, list or follow")
+	cmd.Flags().Int("max-depth", 0, "Do not descend below this depth, directories at the limit are collapsed, 0 for no limit")
+	cmd.Flags().Int("collapse", gopeek.DefaultOptions().CollapseThreshold, "Collapse directories with more entries than this, 0 to disable")
+	cmd.Flags().StringSlice("expand", []string{}, "Patterns of directories never collapsed")
+	cmd.Flags().String("sort", string(gopeek.SortName), "Entry order: name, dirs-first, size or mtime")
+	cmd.Flags().Bool("summary", false, "Append a scan summary section to the output")
+	cmd.Flags().String("structure", string(gopeek.StructureList), "Structure style: list, tree or ascii")
+	cmd.Flags().Bool("sizes", false, "Show file sizes in the structure")
+	cmd.Flags().Bool("line-counts", false, "Show file line counts in the structure")
+	cmd.Flags().Bool("no-emoji", false, "Leave emoji out of the structure and headings")
+	cmd.Flags().StringSlice("slice", []string{}, "Include only part of a file: path:start-end or path#Symbol")
+	cmd.Flags().Bool("outline", false, "Include an outline of source files (Go, Python, TypeScript, JavaScript, Java, Rust) instead of their full content")
+	cmd.Flags().Bool("minify", false, "Strip comments, trailing whitespace and repeated blank lines from source files")
+	cmd.Flags().Bool("overview", false, "Start with an overview of the project manifests (go.mod, package.json, Makefile...)")
+	cmd.Flags().Bool("deps", false, "Add the dependency graph of the Go packages of the scanned modules")
+	cmd.Flags().Bool("line-numbers", false, "Prefix the lines of file contents with their number")
+	cmd.Flags().Bool("tree-only", false, "Output the structure only, without reading files")
+	cmd.Flags().Bool("content-only", false, "Output the file contents only, without the structure")
+	cmd.MarkFlagsMutuallyExclusive("tree-only", "content-only")
+	// Lines are only counted in the files read.
+	cmd.MarkFlagsMutuallyExclusive("tree-only", "line-counts")
+	// Outlines and minified files no longer have the lines of the source.
+	cmd.MarkFlagsMutuallyExclusive("line-numbers", "outline")
+	cmd.MarkFlagsMutuallyExclusive("line-numbers", "minify")
+	cmd.Flags().String("split-size", "", "Split the output into parts of at most this size, in bytes (512KB) or tokens (100k tokens)")
+	cmd.Flags().Bool("verbose", false, "Verbose output")
 }
 
 func Execute() error {
 	return rootCmd.Execute()
 }
 
+// ExecuteContext runs the root command with ctx, which cancels the scan when
+// done. No output file is written for a canceled scan.
+func ExecuteContext(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
+
 func main() {
-	if err := Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		exitFunc(1)
 	}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -22,6 +24,7 @@ func TestRootCmd(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	linkDir := filepath.Join(tmpDir, "links")
 	tests := []struct {
 		name        string
 		args        []string
@@ -51,6 +54,7 @@ func TestRootCmd(t *testing.T) {
 			args:        []string{tmpDir, "-o", filepath.Join(tmpDir, "custom.md"), "--verbose"},
 			expectError: false,
 			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "verbose")
 				outFile := filepath.Join(tmpDir, "custom.md")
 				if _, err := os.Stat(outFile); err != nil {
 					t.Errorf("Expected output file to exist: %v", err)
@@ -61,6 +65,270 @@ func TestRootCmd(t *testing.T) {
 			name:        "With ignore patterns",
 			args:        []string{tmpDir, "-i", "*.log", "-i", "*.tmp"},
 			expectError: false,
+			validate: func(t *testing.T, err error) {
+				// The output is still the one of the previous case.
+				resetFlags(rootCmd, "ignore", "output")
+			},
+		},
+		{
+			name:        "Multiple roots",
+			args:        []string{tmpDir, tmpDir, "-o", filepath.Join(tmpDir, "multi.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				content, err := os.ReadFile(filepath.Join(tmpDir, "multi.md"))
+				if err != nil {
+					t.Fatalf("Expected output file to exist: %v", err)
+				}
+				if strings.Count(string(content), "# 📦 ") != 2 {
+					t.Errorf("Expected one section per root, got:\n%s", content)
+				}
+			},
+		},
+		{
+			name:        "With summary section",
+			args:        []string{tmpDir, "--summary", "-o", filepath.Join(tmpDir, "summary.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "summary")
+				content, err := os.ReadFile(filepath.Join(tmpDir, "summary.md"))
+				if err != nil {
+					t.Fatalf("Expected output file to exist: %v", err)
+				}
+				if !strings.Contains(string(content), "# Scan Summary") {
+					t.Errorf("Expected summary section, got:\n%s", content)
+				}
+			},
+		},
+		{
+			name:        "With symlink policy",
+			args:        []string{linkDir, "--symlinks", "follow", "-o", filepath.Join(tmpDir, "symlinks.md")},
+			expectError: false,
+			setup: func(t *testing.T, cmd *cobra.Command) {
+				if err := os.Mkdir(linkDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(linkDir, "target.txt"), []byte("linked content"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink("target.txt", filepath.Join(linkDir, "link.txt")); err != nil {
+					t.Skipf("symlinks not supported: %v", err)
+				}
+			},
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "symlinks")
+				content, err := os.ReadFile(filepath.Join(tmpDir, "symlinks.md"))
+				if err != nil {
+					t.Fatalf("Expected output file to exist: %v", err)
+				}
+				if !strings.Contains(string(content), "# 📄 link.txt\n```txt\nlinked content\n```") {
+					t.Errorf("Expected the content of the linked file, got:\n%s", content)
+				}
+			},
+		},
+		{
+			name:        "Invalid sort order",
+			args:        []string{tmpDir, "--sort", "random"},
+			expectError: true,
+		},
+		{
+			name:        "With sort order",
+			args:        []string{tmpDir, "--sort", "dirs-first"},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "sort")
+			},
+		},
+		{
+			name:        "Invalid max depth",
+			args:        []string{tmpDir, "--max-depth", "-1"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "max-depth")
+			},
+		},
+		{
+			name:        "Invalid slice",
+			args:        []string{tmpDir, "--slice", "test.txt"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "slice")
+			},
+		},
+		{
+			name:        "Slice matching no file",
+			args:        []string{tmpDir, "--slice", "missing.go:1-2", "-o", filepath.Join(tmpDir, "unmatched.md")},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "slice")
+				if err == nil || !strings.Contains(err.Error(), "--slice missing.go matches no scanned file") {
+					t.Errorf("Expected an error for the unmatched slice, got %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(tmpDir, "unmatched.md")); err == nil {
+					t.Error("Expected no output to be written")
+				}
+			},
+		},
+		{
+			name:        "Unresolved slice",
+			args:        []string{tmpDir, "--slice", "test.txt#Missing", "-o", filepath.Join(tmpDir, "unresolved.md")},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "slice")
+				if err == nil || !strings.Contains(err.Error(), "symbol slices are only supported for Go files") {
+					t.Errorf("Expected an error for the unresolved slice, got %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(tmpDir, "unresolved.md")); err == nil {
+					t.Error("Expected no output to be written")
+				}
+			},
+		},
+		{
+			name:        "With outline",
+			args:        []string{tmpDir, "--outline", "-o", filepath.Join(tmpDir, "outline.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "outline")
+			},
+		},
+		{
+			name:        "With minify",
+			args:        []string{tmpDir, "--minify", "-o", filepath.Join(tmpDir, "minify.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "minify")
+			},
+		},
+		{
+			name:        "With line numbers",
+			args:        []string{tmpDir, "--line-numbers", "-o", filepath.Join(tmpDir, "numbered.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				defer resetFlags(rootCmd, "line-numbers")
+				content, err := os.ReadFile(filepath.Join(tmpDir, "numbered.md"))
+				if err != nil {
+					t.Fatalf("Expected output file to exist: %v", err)
+				}
+				if !strings.Contains(string(content), "```\n1 | test content\n```") {
+					t.Errorf("Expected numbered lines without language, got:\n%s", content)
+				}
+			},
+		},
+		{
+			name:        "Line numbers with outline",
+			args:        []string{tmpDir, "--line-numbers", "--outline"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "line-numbers", "outline")
+			},
+		},
+		{
+			name:        "Line numbers with minify",
+			args:        []string{tmpDir, "--line-numbers", "--minify"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "line-numbers", "minify")
+			},
+		},
+		{
+			name:        "With dependencies",
+			args:        []string{tmpDir, "--deps", "-o", filepath.Join(tmpDir, "deps.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "deps")
+			},
+		},
+		{
+			name:        "With overview",
+			args:        []string{tmpDir, "--overview", "-o", filepath.Join(tmpDir, "overview.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "overview")
+			},
+		},
+		{
+			name:        "Invalid split size",
+			args:        []string{tmpDir, "--split-size", "huge"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "split-size")
+			},
+		},
+		{
+			name:        "Split output to stdout",
+			args:        []string{tmpDir, "--split-size", "1MB", "-o", "-"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "split-size", "output")
+			},
+		},
+		{
+			name:        "With split size",
+			args:        []string{tmpDir, "--split-size", "100k tokens", "-o", filepath.Join(tmpDir, "split.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				defer resetFlags(rootCmd, "split-size")
+				content, err := os.ReadFile(filepath.Join(tmpDir, "split.part1.md"))
+				if err != nil {
+					t.Fatalf("Expected first part to exist: %v", err)
+				}
+				if !strings.HasPrefix(string(content), "# Part 1 of 1\n") {
+					t.Errorf("Expected part header, got:\n%s", content)
+				}
+				if _, err := os.Stat(filepath.Join(tmpDir, "split.md")); !os.IsNotExist(err) {
+					t.Errorf("Expected no unsplit output, got %v", err)
+				}
+			},
+		},
+		{
+			name:        "Invalid structure style",
+			args:        []string{tmpDir, "--structure", "html"},
+			expectError: true,
+		},
+		{
+			name:        "With tree structure",
+			args:        []string{tmpDir, "--structure", "tree", "--no-emoji", "--sizes", "-o", filepath.Join(tmpDir, "tree.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				defer resetFlags(rootCmd, "structure", "no-emoji", "sizes")
+				content, err := os.ReadFile(filepath.Join(tmpDir, "tree.md"))
+				if err != nil {
+					t.Fatalf("Expected output file to exist: %v", err)
+				}
+				if !strings.Contains(string(content), "└── test.txt (12 B)") || !strings.Contains(string(content), "\n# test.txt\n") {
+					t.Errorf("Expected tree structure without emoji, got:\n%s", content)
+				}
+			},
+		},
+		{
+			name:        "Tree and content only together",
+			args:        []string{tmpDir, "--tree-only", "--content-only"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "tree-only", "content-only")
+			},
+		},
+		{
+			name:        "Tree only with line counts",
+			args:        []string{tmpDir, "--tree-only", "--line-counts"},
+			expectError: true,
+			validate: func(t *testing.T, err error) {
+				resetFlags(rootCmd, "tree-only", "line-counts")
+			},
+		},
+		{
+			name:        "Tree only",
+			args:        []string{tmpDir, "--tree-only", "-o", filepath.Join(tmpDir, "map.md")},
+			expectError: false,
+			validate: func(t *testing.T, err error) {
+				defer resetFlags(rootCmd, "tree-only")
+				content, err := os.ReadFile(filepath.Join(tmpDir, "map.md"))
+				if err != nil {
+					t.Fatalf("Expected output file to exist: %v", err)
+				}
+				if !strings.Contains(string(content), "- 📄 test.txt") || strings.Contains(string(content), "test content") {
+					t.Errorf("Expected structure only, got:\n%s", content)
+				}
+			},
 		},
 	}
 
@@ -208,3 +476,191 @@ func TestVersionFlag(t *testing.T) {
 		t.Errorf("Version mismatch:\nExpected to contain: %q\nGot: %q", expectedVersion, output)
 	}
 }
+
+func TestTimeoutFlag(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopeek-cmd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	defer resetFlags(rootCmd, "timeout")
+	resetFlags(rootCmd, "version")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputFile := filepath.Join(tmpDir, "output.md")
+	rootCmd.SetArgs([]string{tmpDir, "--timeout", "1ns", "-o", outputFile})
+
+	err = rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Expected timeout error, got: %v", err)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file after a timeout, got: %v", err)
+	}
+}
+
+func TestExecuteContext_Canceled(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopeek-cmd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	resetFlags(rootCmd, "version")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	defer rootCmd.SetContext(context.Background())
+
+	outputFile := filepath.Join(tmpDir, "output.md")
+	rootCmd.SetArgs([]string{tmpDir, "-o", outputFile})
+
+	err = ExecuteContext(ctx)
+	if err == nil || !strings.Contains(err.Error(), "interrupted") {
+		t.Fatalf("Expected interrupted error, got: %v", err)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file after cancellation, got: %v", err)
+	}
+}
+
+func TestFailOnFlag(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopeek-cmd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	defer resetFlags(rootCmd, "fail-on")
+	resetFlags(rootCmd, "version")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "logo.png"), []byte{0xFF, 0xD8, 0xFF}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputFile := filepath.Join(tmpDir, "output.md")
+	rootCmd.SetArgs([]string{tmpDir, "--fail-on", "binary", "-o", outputFile})
+
+	err = rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "logo.png") {
+		t.Fatalf("Expected policy error mentioning logo.png, got: %v", err)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file when the policy fails, got: %v", err)
+	}
+}
+
+func TestStdoutOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopeek-cmd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	defer resetFlags(rootCmd, "output")
+	resetFlags(rootCmd, "version")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+	rootCmd.SetArgs([]string{tmpDir, "-o", "-"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(stdout.String(), "# Project Structure") || !strings.Contains(stdout.String(), "test content") {
+		t.Errorf("Expected the document on stdout, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "files included: 1") {
+		t.Errorf("Expected the summary on stderr, got:\n%s", stderr.String())
+	}
+	if _, err := os.Stat("-"); !os.IsNotExist(err) {
+		t.Errorf("Expected no file named -, got: %v", err)
+	}
+}
+
+// resetFlags restores the default value of the flags names of cmd, flags
+// keep their value between executions.
+func resetFlags(cmd *cobra.Command, names ...string) {
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		// Setting a slice flag appends to its values.
+		if slice, ok := flag.Value.(interface{ Replace([]string) error }); ok {
+			var values []string
+			if defaults := strings.Trim(flag.DefValue, "[]"); defaults != "" {
+				values = strings.Split(defaults, ",")
+			}
+			slice.Replace(values)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+}
+
+func TestWatchCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "No arguments", args: []string{"watch"}},
+		{name: "Stdout output", args: []string{"watch", tmpDir, "-o", "-"}},
+		{name: "Invalid debounce", args: []string{"watch", tmpDir, "--debounce", "0s"}},
+		{name: "Missing root", args: []string{"watch", filepath.Join(tmpDir, "missing")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer resetFlags(watchCmd, "output", "debounce")
+			rootCmd.SetArgs(tt.args)
+			if err := rootCmd.Execute(); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestWatchCmd_Interrupted(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	outputFile := filepath.Join(outputDir, "watch.md")
+	defer resetFlags(watchCmd, "output")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	rootCmd.SetArgs([]string{"watch", tmpDir, "-o", outputFile})
+	// Subcommands keep the context of a previous execution.
+	watchCmd.SetContext(ctx)
+	defer watchCmd.SetContext(context.Background())
+	go func() { done <- ExecuteContext(ctx) }()
+
+	// The output is written once before any change.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if content, err := os.ReadFile(outputFile); err == nil && strings.Contains(string(content), "test content") {
+			break
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("Expected the output to be written")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error once interrupted, got %v", err)
+	}
+}
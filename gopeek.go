@@ -0,0 +1,379 @@
+// Package gopeek scans a project tree and renders it as a Markdown document
+// holding both its structure and the content of its files.
+//
+// A snapshot is produced with Scan or ScanFS and rendered with
+// RenderMarkdown:
+//
+//	snapshot, err := gopeek.Scan(ctx, "./", gopeek.DefaultOptions())
+//	if err != nil {
+//		return err
+//	}
+//	return gopeek.RenderMarkdown(os.Stdout, snapshot)
+package gopeek
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nouuu/gopeek/internal/lang"
+	"github.com/nouuu/gopeek/internal/logger"
+	"github.com/nouuu/gopeek/internal/manifest"
+	"github.com/nouuu/gopeek/internal/scanner"
+	"github.com/nouuu/gopeek/internal/watch"
+)
+
+type (
+	// Snapshot is the result of scanning a root.
+	Snapshot = scanner.Snapshot
+	// Entry is a node of the project structure.
+	Entry = scanner.Entry
+	// File holds the content of a scanned file.
+	File = scanner.File
+	// Stats summarizes what happened during a scan.
+	Stats = scanner.Stats
+	// LanguageStats holds the totals of included files for one language.
+	LanguageStats = scanner.LanguageStats
+	// SymlinkPolicy controls how symbolic links are handled.
+	SymlinkPolicy = scanner.SymlinkPolicy
+	// SortOrder controls the order of entries and files.
+	SortOrder = scanner.SortOrder
+	// Progress describes the state of a running scan.
+	Progress = scanner.Progress
+	// Markdown renders snapshots as a Markdown document.
+	Markdown = scanner.Markdown
+	// StructureStyle controls how the project structure is rendered.
+	StructureStyle = scanner.StructureStyle
+	// PathError records a path that was skipped or only partially included.
+	PathError = scanner.PathError
+	// ErrorKind categorizes path errors.
+	ErrorKind = scanner.ErrorKind
+	// Slice selects the part of a file to include.
+	Slice = scanner.Slice
+	// LineRange is an inclusive range of line numbers.
+	LineRange = scanner.LineRange
+	// Package is a Go package of the scanned modules and its internal imports.
+	Package = scanner.Package
+	// Manifest summarizes a project manifest such as go.mod or package.json.
+	Manifest = manifest.Manifest
+	// SplitSize is the maximum size of the parts of a split document.
+	SplitSize = scanner.SplitSize
+	// Summarizer turns the content of a source file into an outline.
+	Summarizer = lang.Summarizer
+	// SummarizerFunc adapts a function to the Summarizer interface.
+	SummarizerFunc = lang.SummarizerFunc
+)
+
+const (
+	SymlinkSkip   = scanner.SymlinkSkip
+	SymlinkList   = scanner.SymlinkList
+	SymlinkFollow = scanner.SymlinkFollow
+)
+
+const (
+	SortName      = scanner.SortName
+	SortDirsFirst = scanner.SortDirsFirst
+	SortSize      = scanner.SortSize
+	SortMtime     = scanner.SortMtime
+)
+
+const (
+	StructureList  = scanner.StructureList
+	StructureTree  = scanner.StructureTree
+	StructureASCII = scanner.StructureASCII
+)
+
+const (
+	ErrorPermission = scanner.ErrorPermission
+	ErrorTooLarge   = scanner.ErrorTooLarge
+	ErrorBinary     = scanner.ErrorBinary
+	ErrorRead       = scanner.ErrorRead
+	ErrorSlice      = scanner.ErrorSlice
+)
+
+// DefaultIgnorePatterns are the patterns ignored by DefaultOptions.
+var DefaultIgnorePatterns = scanner.DefaultIgnorePatterns
+
+// Options configures a scan. The zero value ignores nothing but the
+// .gitignore of the root, lists symbolic links and sorts entries by name.
+type Options struct {
+	IgnorePatterns []string
+	Symlinks       SymlinkPolicy
+	Sort           SortOrder
+
+	// MaxDepth limits the depth of the walk, directories at the limit are
+	// collapsed into a summary. 0 means no limit.
+	MaxDepth int
+
+	// CollapseThreshold collapses directories holding more entries than this
+	// into a summary, unless they match ExpandPatterns. 0 disables it.
+	CollapseThreshold int
+	ExpandPatterns    []string
+
+	// Slices restrict the content of the files they match to some lines.
+	Slices []Slice
+
+	// Outline replaces the content of source files with a summary of their
+	// declarations. Go, Python, TypeScript, JavaScript, Java and Rust are
+	// supported out of the box.
+	Outline bool
+
+	// Summarizers add or replace the summarizer used by Outline for some
+	// languages, keyed by the ID of the language (go, python, typescript,
+	// javascript, java, rust, c, cpp, kotlin, shell, yaml... as listed in the
+	// README) or by the file extension without the dot, the ID winning when
+	// both are set.
+	Summarizers map[string]Summarizer
+
+	// Minify strips comments, trailing whitespace and repeated blank lines
+	// from the content of source files, string literals being kept intact.
+	Minify bool
+
+	// Overview adds a summary of the manifests at the root of the project:
+	// name, versions, dependencies, scripts and entrypoints.
+	Overview bool
+
+	// Dependencies adds the graph of the Go packages of the scanned modules,
+	// built from their go.mod files and imports.
+	Dependencies bool
+
+	// SkipContent lists files without reading them, for structure-only output.
+	SkipContent bool
+
+	// Output is the path of the generated document, left out of the scan.
+	Output string
+
+	// Logger receives debug and warning messages. Nothing is logged when nil.
+	Logger *slog.Logger
+
+	// OnProgress, when set, is called after each file is processed. The
+	// expected total is only known by Scan, ScanFS never walks fsys from
+	// several goroutines.
+	OnProgress func(Progress)
+}
+
+func DefaultOptions() Options {
+	cfg := scanner.DefaultConfig()
+	return Options{
+		IgnorePatterns: cfg.IgnorePatterns,
+		Symlinks:       cfg.Symlinks,
+		Sort:           cfg.Sort,
+
+		CollapseThreshold: cfg.CollapseThreshold,
+	}
+}
+
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, error) {
+	return scanner.ParseSymlinkPolicy(value)
+}
+
+func ParseSortOrder(value string) (SortOrder, error) {
+	return scanner.ParseSortOrder(value)
+}
+
+func ParseStructureStyle(value string) (StructureStyle, error) {
+	return scanner.ParseStructureStyle(value)
+}
+
+// ParseSlice parses a slice written as path:start-end, path:line,
+// path:start- or path#Symbol, where path may be the last elements of the
+// file path.
+func ParseSlice(value string) (Slice, error) {
+	return scanner.ParseSlice(value)
+}
+
+// ParseSplitSize parses a size in bytes, such as 512KB, or in tokens, such
+// as 100k tokens.
+func ParseSplitSize(value string) (SplitSize, error) {
+	return scanner.ParseSplitSize(value)
+}
+
+func ParseErrorKind(value string) (ErrorKind, error) {
+	return scanner.ParseErrorKind(value)
+}
+
+// CheckErrors returns an error joining the path errors of snapshots whose
+// kind is one of kinds, or nil when there are none. Scans never fail on path
+// errors themselves, CheckErrors lets callers decide which ones matter.
+func CheckErrors(kinds []ErrorKind, snapshots ...*Snapshot) error {
+	return scanner.CheckErrors(kinds, snapshots...)
+}
+
+// Scan walks root, a directory or a .zip, .tar or .tar.gz archive, and
+// returns its snapshot. The scan is aborted with ctx's error when ctx is done.
+func Scan(ctx context.Context, root string, opts Options) (*Snapshot, error) {
+	return scan(ctx, scanner.New(root, opts.config(), opts.logger()))
+}
+
+// ScanFS walks fsys and returns its snapshot. name identifies the root in
+// the rendered document.
+func ScanFS(ctx context.Context, name string, fsys fs.FS, opts Options) (*Snapshot, error) {
+	return scan(ctx, scanner.NewFS(name, fsys, opts.config(), opts.logger()))
+}
+
+// Watch scans roots, then keeps their snapshots up to date as their files
+// change, until ctx is done. update is called with copies of the snapshots,
+// which it may keep, after the first scan and after each batch of changes
+// that altered them. Changes are batched until no file changed for
+// debounce, and only the changed paths are read again. Roots must be
+// directories, archives cannot be watched.
+func Watch(ctx context.Context, roots []string, opts Options, debounce time.Duration, update func([]*Snapshot) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	log := opts.logger()
+
+	// The watchers ask the scanners which paths are ignored while they are
+	// being updated.
+	var mu sync.Mutex
+	scanners := make([]*scanner.Scanner, len(roots))
+	watchers := make([]*watch.Watcher, len(roots))
+	for i, root := range roots {
+		if info, err := os.Stat(root); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("cannot watch %s: not a directory", root)
+		}
+		s := scanner.New(root, opts.config(), log)
+		if err := s.Scan(ctx); err != nil {
+			return err
+		}
+		w, err := watch.New(root, debounce, func(name string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return s.Ignores(name)
+		})
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		scanners[i], watchers[i] = s, w
+	}
+
+	snapshots := func() []*Snapshot {
+		result := make([]*Snapshot, len(scanners))
+		for i, s := range scanners {
+			result[i] = s.Snapshot().Clone()
+		}
+		return result
+	}
+	if err := update(snapshots()); err != nil {
+		return err
+	}
+
+	type change struct {
+		root  int
+		names []string
+	}
+	changes, errs := make(chan change), make(chan error)
+	for i, w := range watchers {
+		go func() {
+			for {
+				select {
+				case names, ok := <-w.Changes():
+					if !ok {
+						return
+					}
+					select {
+					case changes <- change{i, names}:
+					case <-ctx.Done():
+						return
+					}
+				case err := <-w.Errors():
+					select {
+					case errs <- fmt.Errorf("root %s: %w", roots[i], err):
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case c := <-changes:
+			log.Debug("files changed", "root", roots[c.root], "paths", c.names)
+			mu.Lock()
+			changed, err := scanners[c.root].Update(ctx, c.names)
+			mu.Unlock()
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("root %s: %w", roots[c.root], err)
+			}
+			// Directories may have stopped being ignored.
+			if slices.Contains(c.names, ".gitignore") || slices.Contains(c.names, ".") {
+				if err := watchers[c.root].Add("."); err != nil {
+					return fmt.Errorf("root %s: %w", roots[c.root], err)
+				}
+			}
+			if !changed {
+				continue
+			}
+			if err := update(snapshots()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func scan(ctx context.Context, s *scanner.Scanner) (*Snapshot, error) {
+	if err := s.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return s.Snapshot(), nil
+}
+
+// RenderMarkdown writes snapshots to w as a single Markdown document, with
+// one section per snapshot when there are several. Use Markdown to change
+// the rendering options.
+func RenderMarkdown(w io.Writer, snapshots ...*Snapshot) error {
+	return scanner.RenderMarkdown(w, snapshots...)
+}
+
+func (o Options) config() scanner.Config {
+	return scanner.Config{
+		Output:         o.Output,
+		IgnorePatterns: o.IgnorePatterns,
+		Symlinks:       o.Symlinks,
+		Sort:           o.Sort,
+		MaxDepth:       o.MaxDepth,
+		Slices:         o.Slices,
+		Outline:        o.Outline,
+		Summarizers:    o.Summarizers,
+		Minify:         o.Minify,
+		Overview:       o.Overview,
+		Dependencies:   o.Dependencies,
+		SkipContent:    o.SkipContent,
+		OnProgress:     o.OnProgress,
+
+		CollapseThreshold: o.CollapseThreshold,
+		ExpandPatterns:    o.ExpandPatterns,
+	}
+}
+
+func (o Options) logger() *logger.Logger {
+	if o.Logger == nil {
+		return &logger.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	}
+	return &logger.Logger{Logger: o.Logger}
+}
+
+// WriteFile renders snapshots as Markdown into the file at path. The file is
+// replaced atomically, keeping its permissions when it already exists.
+func WriteFile(path string, snapshots ...*Snapshot) error {
+	return Markdown{}.WriteFile(path, snapshots...)
+}
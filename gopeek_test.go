@@ -0,0 +1,209 @@
+package gopeek
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestScanFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":         &fstest.MapFile{Data: []byte("package main")},
+		"internal/a/a.go": &fstest.MapFile{Data: []byte("package a")},
+		"node_modules/x":  &fstest.MapFile{Data: []byte("ignored")},
+	}
+
+	snapshot, err := ScanFS(context.Background(), "project", fsys, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if snapshot.Root != "project" {
+		t.Errorf("Root = %q, want %q", snapshot.Root, "project")
+	}
+
+	var paths []string
+	for _, entry := range snapshot.Entries {
+		paths = append(paths, entry.Path)
+	}
+	expected := []string{"internal", "internal/a", "internal/a/a.go", "main.go"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Entries = %v, want %v", paths, expected)
+	}
+
+	if len(snapshot.Files) != 2 || snapshot.Files[1].Language != "go" || snapshot.Files[1].Content != "package main" {
+		t.Errorf("Unexpected files: %+v", snapshot.Files)
+	}
+}
+
+func TestScan_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Scan(ctx, ".", DefaultOptions()); err == nil {
+		t.Error("Expected error for canceled context")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopeek-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(tmpDir, "output.md")
+
+	snapshot, err := Scan(context.Background(), tmpDir, opts)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := WriteFile(opts.Output, snapshot); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	content, err := os.ReadFile(opts.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "# 📄 test.txt\n```txt\ntest content\n```") {
+		t.Errorf("Unexpected output:\n%s", content)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	paths := func(snapshot *Snapshot) []string {
+		var paths []string
+		for _, file := range snapshot.Files {
+			paths = append(paths, file.Path)
+		}
+		return paths
+	}
+	updates := make(chan *Snapshot)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, []string{tmpDir}, DefaultOptions(), 20*time.Millisecond, func(snapshots []*Snapshot) error {
+			select {
+			case updates <- snapshots[0]:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}()
+
+	expect := func(want []string) *Snapshot {
+		t.Helper()
+		select {
+		case snapshot := <-updates:
+			if got := paths(snapshot); strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("Files = %v, want %v", got, want)
+			}
+			return snapshot
+		case err := <-done:
+			t.Fatalf("Watch stopped early: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected an update with %v", want)
+		}
+		return nil
+	}
+	expect([]string{"main.go"})
+	if err := os.WriteFile(filepath.Join(tmpDir, "util.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kept := expect([]string{"main.go", "util.go"})
+	if err := os.Remove(filepath.Join(tmpDir, "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	expect([]string{"util.go"})
+	if got := paths(kept); strings.Join(got, ",") != "main.go,util.go" {
+		t.Errorf("Expected snapshots to be left untouched by later updates, got %v", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error once canceled, got %v", err)
+	}
+}
+
+func TestWatch_NotDirectory(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "project.zip")
+	if err := os.WriteFile(archive, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := Watch(context.Background(), []string{archive}, DefaultOptions(), time.Millisecond, func([]*Snapshot) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("Expected an error for an archive, got %v", err)
+	}
+}
+
+func TestWatch_RelativeOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// The root is absolute and the output relative to the working directory,
+	// writing the output must not be reported as a change.
+	opts := DefaultOptions()
+	opts.Output = "out.md"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan *Snapshot, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, []string{tmpDir}, opts, 20*time.Millisecond, func(snapshots []*Snapshot) error {
+			select {
+			case updates <- snapshots[0]:
+			default:
+			}
+			return WriteFile(opts.Output, snapshots...)
+		})
+	}()
+
+	select {
+	case snapshot := <-updates:
+		for _, file := range snapshot.Files {
+			if file.Path == "out.md" {
+				t.Error("Expected the output to be left out of the scan")
+			}
+		}
+	case err := <-done:
+		t.Fatalf("Watch stopped early: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a first update")
+	}
+	select {
+	case <-updates:
+		t.Error("Expected writing the output not to trigger an update")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error once canceled, got %v", err)
+	}
+}
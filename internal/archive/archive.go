@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+var extensions = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive reports whether name has an archive extension supported by Open.
+func IsArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open exposes the contents of the archive at name as an fs.FS, nothing is
+// extracted to disk. Zip entries are read on demand, the file system then
+// implements io.Closer and holds the archive open until closed. Tar archives
+// are streamed into memory, keeping only the size of entries over maxSize.
+// Reading stops as soon as ctx is done.
+func Open(ctx context.Context, name string, maxSize int64) (fs.FS, error) {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".zip") {
+		return zip.OpenReader(name)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch {
+	case strings.HasSuffix(lower, ".tar"):
+		return readTar(ctx, bufio.NewReader(f), maxSize)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bufio.NewReader(f))
+		if err != nil {
+			return nil, fmt.Errorf("error opening gzip stream: %w", err)
+		}
+		defer gz.Close()
+		return readTar(ctx, gz, maxSize)
+	}
+	return nil, fmt.Errorf("unsupported archive format: %s", name)
+}
+
+// ReadLink returns the target of the symbolic link name. Archives store link
+// targets as the entry content, which is used when fsys has no ReadLink method.
+func ReadLink(fsys fs.FS, name string) (string, error) {
+	if rl, ok := fsys.(interface {
+		ReadLink(name string) (string, error)
+	}); ok {
+		return rl.ReadLink(name)
+	}
+	target, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	return string(target), nil
+}
+
+func readTar(ctx context.Context, r io.Reader, maxSize int64) (fs.FS, error) {
+	fsys := newMemFS()
+	tr := tar.NewReader(r)
+	// Hard links are resolved once their target is known.
+	var links []*tar.Header
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading tar entry: %w", err)
+		}
+
+		name := cleanName(hdr.Name)
+		if name == "" {
+			continue
+		}
+
+		info := hdr.FileInfo()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fsys.add(name, info.Mode(), info.ModTime(), nil, 0)
+		case tar.TypeReg:
+			if hdr.Size > maxSize {
+				// Too large to be included, the size is enough to tell.
+				fsys.add(name, info.Mode(), info.ModTime(), nil, hdr.Size)
+				continue
+			}
+			data, err := io.ReadAll(io.LimitReader(tr, maxSize))
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", hdr.Name, err)
+			}
+			fsys.add(name, info.Mode(), info.ModTime(), data, int64(len(data)))
+		case tar.TypeSymlink:
+			fsys.add(name, info.Mode(), info.ModTime(), []byte(hdr.Linkname), int64(len(hdr.Linkname)))
+		case tar.TypeLink:
+			links = append(links, hdr)
+		}
+	}
+
+	for _, hdr := range links {
+		name, target := cleanName(hdr.Name), cleanName(hdr.Linkname)
+		if entry, ok := fsys.entries[target]; ok && target != "" && entry.mode.IsRegular() {
+			fsys.add(name, entry.mode, hdr.ModTime, entry.data, entry.size)
+		}
+	}
+	return fsys, nil
+}
+
+// cleanName turns an archive entry name into a valid fs.FS path, or returns
+// an empty string for entries that would escape the archive root.
+func cleanName(name string) string {
+	name = path.Clean(strings.TrimLeft(name, "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,253 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testFiles = map[string]string{
+	"main.go":            "package main",
+	"internal/a/a.go":    "package a",
+	"internal/a/b/b.txt": "nested",
+}
+
+func writeZip(t *testing.T, w io.Writer) {
+	zw := zip.NewWriter(w)
+	for name, content := range testFiles {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTar(t *testing.T, w io.Writer) {
+	tw := tar.NewWriter(w)
+	for name, content := range testFiles {
+		hdr := &tar.Header{Name: "./" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := []*tar.Header{
+		{Name: "link.go", Linkname: "main.go", Typeflag: tar.TypeSymlink, Mode: 0o777},
+		{Name: "../escape.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+	}
+	for _, hdr := range links {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	tests := map[string]bool{
+		"release.zip":    true,
+		"release.tar":    true,
+		"release.tar.gz": true,
+		"release.TGZ":    true,
+		"release.gz":     false,
+		"project":        false,
+		"main.go":        false,
+	}
+	for name, expected := range tests {
+		if result := IsArchive(name); result != expected {
+			t.Errorf("IsArchive(%q) = %v, want %v", name, result, expected)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "archive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		name  string
+		file  string
+		write func(*testing.T, io.Writer)
+	}{
+		{name: "Zip", file: "release.zip", write: writeZip},
+		{name: "Tar", file: "release.tar", write: writeTar},
+		{
+			name: "Tar gzip",
+			file: "release.tar.gz",
+			write: func(t *testing.T, w io.Writer) {
+				gz := gzip.NewWriter(w)
+				writeTar(t, gz)
+				if err := gz.Close(); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.file)
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.write(t, f)
+			f.Close()
+
+			fsys, err := Open(context.Background(), path, 1024)
+			if err != nil {
+				t.Fatalf("Open(%q) error: %v", path, err)
+			}
+
+			if err := fstest.TestFS(fsys, "main.go", "internal/a/a.go", "internal/a/b/b.txt"); err != nil {
+				t.Error(err)
+			}
+			for name, content := range testFiles {
+				data, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					t.Fatalf("ReadFile(%q) error: %v", name, err)
+				}
+				if string(data) != content {
+					t.Errorf("ReadFile(%q) = %q, want %q", name, data, content)
+				}
+			}
+			if _, err := fs.Stat(fsys, "escape.txt"); err == nil {
+				t.Error("Expected entries escaping the archive root to be dropped")
+			}
+		})
+	}
+}
+
+func TestReadLink(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "archive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "links.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTar(t, f)
+	f.Close()
+
+	fsys, err := Open(context.Background(), path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := ReadLink(fsys, "link.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "main.go" {
+		t.Errorf("ReadLink() = %q, want %q", target, "main.go")
+	}
+	if _, err := ReadLink(fsys, "main.go"); err == nil {
+		t.Error("Expected error reading a regular file as a link")
+	}
+}
+
+func TestOpen_Tar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	entries := []struct {
+		hdr     *tar.Header
+		content string
+	}{
+		{hdr: &tar.Header{Name: "small.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 5}, content: "small"},
+		{hdr: &tar.Header{Name: "large.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 20}, content: strings.Repeat("x", 20)},
+		{hdr: &tar.Header{Name: "hard.txt", Linkname: "./small.txt", Typeflag: tar.TypeLink, Mode: 0o644}},
+		{hdr: &tar.Header{Name: "dangling.txt", Linkname: "missing.txt", Typeflag: tar.TypeLink, Mode: 0o644}},
+		// Malformed archives listing paths as both files and directories.
+		{hdr: &tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4}, content: "file"},
+		{hdr: &tar.Header{Name: "file/child.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 5}, content: "child"},
+		{hdr: &tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{hdr: &tar.Header{Name: "dir", Typeflag: tar.TypeReg, Mode: 0o644, Size: 3}, content: "dir"},
+	}
+	for _, entry := range entries {
+		if err := tw.WriteHeader(entry.hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	fsys, err := Open(context.Background(), path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fs.Stat(fsys, "large.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 20 {
+		t.Errorf("Size() = %d, want the header size 20", info.Size())
+	}
+	if data, _ := fs.ReadFile(fsys, "large.txt"); len(data) != 0 {
+		t.Errorf("Expected entries over the maximum size not to be read, got %d bytes", len(data))
+	}
+
+	data, err := fs.ReadFile(fsys, "hard.txt")
+	if err != nil {
+		t.Fatalf("ReadFile(hard.txt) error: %v", err)
+	}
+	if string(data) != "small" {
+		t.Errorf("ReadFile(hard.txt) = %q, want the content of its target", data)
+	}
+	if _, err := fs.Stat(fsys, "dangling.txt"); err == nil {
+		t.Error("Expected hard links to missing entries to be dropped")
+	}
+
+	if data, err := fs.ReadFile(fsys, "file"); err != nil || string(data) != "file" {
+		t.Errorf("ReadFile(file) = %q, %v, want the first entry of the path", data, err)
+	}
+	if _, err := fs.Stat(fsys, "file/child.txt"); err == nil {
+		t.Error("Expected entries below a file to be dropped")
+	}
+	if info, err := fs.Stat(fsys, "dir"); err != nil || !info.IsDir() {
+		t.Errorf("Expected dir to stay a directory, got %v", err)
+	}
+	if err := fstest.TestFS(fsys, "small.txt", "hard.txt", "file"); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Open(ctx, path, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("Open() with a canceled context error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// memFS is a read-only in-memory file system holding the entries of an
+// archive. Parent directories are created implicitly.
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+type memEntry struct {
+	name     string
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte
+	size     int64 // size of the entry, data is left empty when too large
+	children map[string]*memEntry
+}
+
+func newMemFS() *memFS {
+	root := &memEntry{name: ".", mode: fs.ModeDir | 0o755, children: map[string]*memEntry{}}
+	return &memFS{entries: map[string]*memEntry{".": root}}
+}
+
+// add records the entry name. Malformed archives may list a path both as a
+// file and as a directory: the first kind wins and the conflicting entries
+// are dropped.
+func (m *memFS) add(name string, mode fs.FileMode, modTime time.Time, data []byte, size int64) {
+	entry, ok := m.entries[name]
+	if ok && entry.mode.IsDir() != mode.IsDir() {
+		return
+	}
+	if !ok {
+		parent := m.dir(path.Dir(name))
+		if parent == nil {
+			return
+		}
+		entry = &memEntry{name: path.Base(name)}
+		m.entries[name] = entry
+		parent.children[entry.name] = entry
+	}
+	entry.mode = mode
+	entry.modTime = modTime
+	entry.data = data
+	entry.size = size
+	if mode.IsDir() && entry.children == nil {
+		entry.children = map[string]*memEntry{}
+	}
+}
+
+// dir returns the directory entry for name, creating it and its parents, or
+// nil when name or one of its parents is not a directory.
+func (m *memFS) dir(name string) *memEntry {
+	if _, ok := m.entries[name]; !ok {
+		m.add(name, fs.ModeDir|0o755, time.Time{}, nil, 0)
+	}
+	if entry, ok := m.entries[name]; ok && entry.mode.IsDir() {
+		return entry
+	}
+	return nil
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{entry: entry, reader: bytes.NewReader(entry.data)}, nil
+}
+
+func (m *memFS) ReadLink(name string) (string, error) {
+	entry, ok := m.entries[name]
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(entry.data), nil
+}
+
+type memFile struct {
+	entry  *memEntry
+	reader *bytes.Reader
+	offset int
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return memInfo{f.entry}, nil }
+func (f *memFile) Close() error               { return nil }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.entry.mode.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.entry.name, Err: fs.ErrInvalid}
+	}
+	return f.reader.Read(p)
+}
+
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.entry.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.entry.name, Err: fs.ErrInvalid}
+	}
+
+	names := make([]string, 0, len(f.entry.children))
+	for name := range f.entry.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = names[f.offset:]
+
+	if n > 0 && len(names) > n {
+		names = names[:n]
+	}
+	if n > 0 && len(names) == 0 {
+		return nil, io.EOF
+	}
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, fs.FileInfoToDirEntry(memInfo{f.entry.children[name]}))
+	}
+	f.offset += len(entries)
+	return entries, nil
+}
+
+type memInfo struct {
+	entry *memEntry
+}
+
+func (i memInfo) Name() string       { return i.entry.name }
+func (i memInfo) Size() int64        { return i.entry.size }
+func (i memInfo) Mode() fs.FileMode  { return i.entry.mode }
+func (i memInfo) ModTime() time.Time { return i.entry.modTime }
+func (i memInfo) IsDir() bool        { return i.entry.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
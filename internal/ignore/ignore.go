@@ -2,6 +2,7 @@ package ignore
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -58,7 +59,11 @@ func (m *Matcher) LoadFile(path string) error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	return m.Load(file)
+}
+
+func (m *Matcher) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		m.AddPattern(scanner.Text())
 	}
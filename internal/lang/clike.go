@@ -0,0 +1,160 @@
+package lang
+
+import (
+	"regexp"
+	"strings"
+)
+
+// braceSummarizer summarizes languages delimiting blocks with braces. Blocks
+// opened by a container declaration (class, interface, impl, ...) are kept
+// with their members, every other block, such as a function body or an
+// initializer, is elided.
+type braceSummarizer struct {
+	// container matches the header of declarations whose block is kept.
+	container *regexp.Regexp
+	// quotes are the characters delimiting string literals.
+	quotes string
+	// charLiterals reports whether single quotes delimit character literals
+	// only, so that they can be told apart from Rust lifetimes.
+	charLiterals bool
+}
+
+var (
+	typescriptSummarizer = braceSummarizer{
+		container: regexp.MustCompile(`^(export\s+)?(default\s+)?(declare\s+)?(abstract\s+)?(class|interface|enum|namespace|module)\b|^(export\s+)?(declare\s+)?type\s+\w+.*=\s*\{|^declare\s+global\b`),
+		quotes:    "\"'`",
+	}
+	javascriptSummarizer = braceSummarizer{
+		container: regexp.MustCompile(`^(export\s+)?(default\s+)?class\b`),
+		quotes:    "\"'`",
+	}
+	javaSummarizer = braceSummarizer{
+		container:    regexp.MustCompile(`^((public|protected|private|static|final|abstract|sealed|non-sealed|strictfp)\s+)*(class|interface|enum|record|@interface)\b`),
+		quotes:       `"`,
+		charLiterals: true,
+	}
+	rustSummarizer = braceSummarizer{
+		container:    regexp.MustCompile(`^(pub(\([^)]*\))?\s+)?(unsafe\s+)?(struct|enum|trait|impl|mod|union)\b`),
+		quotes:       `"`,
+		charLiterals: true,
+	}
+)
+
+var charLiteral = regexp.MustCompile(`^'(\\.[^']*|[^'\\])'`)
+
+func (b braceSummarizer) Summarize(name, content string) (string, error) {
+	var out []string
+	header := "" // declaration text since the end of the last statement
+	skip := 0    // depth of the elided block being skipped
+	inComment := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		code := b.mask(line, &inComment)
+		if skip > 0 {
+			skip += strings.Count(code, "{") - strings.Count(code, "}")
+			if skip < 0 {
+				skip = 0
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			out = appendBlank(out)
+			header = ""
+			continue
+		}
+
+		codeTrimmed := strings.TrimSpace(code)
+		if codeTrimmed == "" {
+			// Comment lines are kept as they are.
+			out = append(out, line)
+			continue
+		}
+
+		header = strings.TrimSpace(header + " " + codeTrimmed)
+		unmatched := unmatchedOpenings(code)
+		switch {
+		case len(unmatched) > 0 && !b.container.MatchString(header):
+			out = append(out, line[:unmatched[0]+1]+" ... }")
+			skip = len(unmatched)
+		default:
+			// Statements, container headers and the braces closing them.
+			out = append(out, line)
+		}
+
+		if len(unmatched) > 0 || strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, "#[") ||
+			strings.ContainsAny(codeTrimmed[len(codeTrimmed)-1:], ";},") {
+			header = ""
+		}
+	}
+	return joinLines(out), nil
+}
+
+// unmatchedOpenings returns the positions of the braces of code that are not
+// closed on the same line.
+func unmatchedOpenings(code string) []int {
+	var open []int
+	for i := 0; i < len(code); i++ {
+		switch code[i] {
+		case '{':
+			open = append(open, i)
+		case '}':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return open
+}
+
+// mask returns line with its comments and string literals replaced by
+// spaces, keeping the positions of the remaining code. inComment tracks
+// block comments spanning several lines.
+func (b braceSummarizer) mask(line string, inComment *bool) string {
+	out := []byte(line)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case *inComment:
+			if strings.HasPrefix(line[i:], "*/") {
+				out[i+1] = ' '
+				*inComment = false
+			}
+			out[i] = ' '
+			if !*inComment {
+				i++
+			}
+		case strings.HasPrefix(line[i:], "/*"):
+			*inComment = true
+			out[i], out[i+1] = ' ', ' '
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			for j := i; j < len(line); j++ {
+				out[j] = ' '
+			}
+			return string(out)
+		case strings.IndexByte(b.quotes, line[i]) >= 0:
+			quote := line[i]
+			out[i] = ' '
+			for i++; i < len(line) && line[i] != quote; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					out[i] = ' '
+					i++
+				}
+				out[i] = ' '
+			}
+			if i < len(line) {
+				out[i] = ' '
+			}
+		case line[i] == '\'' && b.charLiterals:
+			if loc := charLiteral.FindStringIndex(line[i:]); loc != nil {
+				for j := i; j < i+loc[1]; j++ {
+					out[j] = ' '
+				}
+				i += loc[1] - 1
+			}
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,168 @@
+package lang
+
+import "testing"
+
+func TestBraceSummarizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		summarizer Summarizer
+		source     string
+		expected   string
+	}{
+		{
+			name:       "TypeScript",
+			summarizer: typescriptSummarizer,
+			source: `import { readFile } from "fs";
+
+/** Options of the scan. */
+export interface Options {
+  root: string;
+  nested: {
+    deep: number;
+  };
+}
+
+export const DEFAULTS = {
+  root: ".",
+};
+
+export class Scanner {
+  private readonly root: string;
+
+  /* Runs the scan. */
+  async run(options: Options): Promise<string[]> {
+    const pattern = "{not a brace";
+    if (options.root) {
+      return [pattern];
+    }
+    return [];
+  }
+}
+
+export function scan(
+  root: string,
+): Promise<string[]> {
+  return new Scanner(root).run();
+}
+`,
+			expected: `import { readFile } from "fs";
+
+/** Options of the scan. */
+export interface Options {
+  root: string;
+  nested: { ... }
+}
+
+export const DEFAULTS = { ... }
+
+export class Scanner {
+  private readonly root: string;
+
+  /* Runs the scan. */
+  async run(options: Options): Promise<string[]> { ... }
+}
+
+export function scan(
+  root: string,
+): Promise<string[]> { ... }
+`,
+		},
+		{
+			name:       "JavaScript",
+			summarizer: javascriptSummarizer,
+			source:     "const helper = (x) => {\n  return `${x}}`;\n};\n\nclass View extends Base {\n  render() {\n    return '}';\n  }\n}\n\nmodule.exports = { View, helper };\n",
+			expected:   "const helper = (x) => { ... }\n\nclass View extends Base {\n  render() { ... }\n}\n\nmodule.exports = { View, helper };\n",
+		},
+		{
+			name:       "Java",
+			summarizer: javaSummarizer,
+			source: `package com.example;
+
+@Service
+public final class Scanner implements Runnable
+{
+    private static final char BRACE = '{';
+
+    @Override
+    public void run()
+    {
+        for (String root : roots) {
+            System.out.println("}" + root);
+        }
+    }
+
+    public enum Mode {
+        FAST, FULL;
+    }
+}
+`,
+			expected: `package com.example;
+
+@Service
+public final class Scanner implements Runnable
+{
+    private static final char BRACE = '{';
+
+    @Override
+    public void run()
+    { ... }
+
+    public enum Mode {
+        FAST, FULL;
+    }
+}
+`,
+		},
+		{
+			name:       "Rust",
+			summarizer: rustSummarizer,
+			source: `use std::fs;
+
+/// A scanner.
+#[derive(Debug)]
+pub struct Scanner<'a> {
+    root: &'a str,
+}
+
+impl<'a> Scanner<'a> {
+    pub fn run(&self) -> Vec<String> {
+        let c = '{';
+        vec![format!("{}", self.root)]
+    }
+}
+
+pub trait Walk {
+    fn walk(&self) -> usize;
+}
+`,
+			expected: `use std::fs;
+
+/// A scanner.
+#[derive(Debug)]
+pub struct Scanner<'a> {
+    root: &'a str,
+}
+
+impl<'a> Scanner<'a> {
+    pub fn run(&self) -> Vec<String> { ... }
+}
+
+pub trait Walk {
+    fn walk(&self) -> usize;
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := tt.summarizer.Summarize("file", tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary != tt.expected {
+				t.Errorf("Summarize() =\n%s\nwant:\n%s", summary, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package lang
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+)
+
+// summarizeGo returns the declarations of the Go source content: package
+// clause, imports, types, variables, constants and function signatures with
+// their doc comments. Function bodies are left out.
+func summarizeGo(name, content string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, content, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("error parsing %s: %w", name, err)
+	}
+
+	var bodies []*ast.BlockStmt
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			bodies = append(bodies, fn.Body)
+			fn.Body = nil
+		}
+	}
+
+	// Comments inside the removed bodies would be printed out of place.
+	comments := file.Comments[:0]
+	for _, group := range file.Comments {
+		if !within(group, bodies) {
+			comments = append(comments, group)
+		}
+	}
+	file.Comments = comments
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return "", fmt.Errorf("error printing outline of %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func within(node ast.Node, blocks []*ast.BlockStmt) bool {
+	for _, block := range blocks {
+		if node.Pos() >= block.Pos() && node.End() <= block.End() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package lang
+
+import "testing"
+
+func TestSummarizeGo(t *testing.T) {
+	source := `// Package demo is a demo.
+package demo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limit is the maximum size.
+const Limit = 10
+
+// Scanner scans.
+type Scanner struct {
+	root string // root directory
+}
+
+// Run runs the scan.
+func (s *Scanner) Run(name string) error {
+	// An inline comment that must not be kept.
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("empty name")
+	}
+	return nil
+}
+
+func helper[T any](v T) T { return v }
+`
+	expected := `// Package demo is a demo.
+package demo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limit is the maximum size.
+const Limit = 10
+
+// Scanner scans.
+type Scanner struct {
+	root string // root directory
+}
+
+// Run runs the scan.
+func (s *Scanner) Run(name string) error
+
+func helper[T any](v T) T
+`
+
+	outline, err := summarizeGo("demo.go", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outline != expected {
+		t.Errorf("summarizeGo() =\n%s\nwant:\n%s", outline, expected)
+	}
+
+	if _, err := summarizeGo("broken.go", "package"); err == nil {
+		t.Error("Expected error for invalid source")
+	}
+}
@@ -0,0 +1,122 @@
+// Package lang identifies the language of source files, summarizes them
+// into an outline of their declarations and strips their comments.
+package lang
+
+import (
+	"path"
+	"strings"
+)
+
+// Language describes a language known to the registry.
+type Language struct {
+	ID         string
+	Extensions []string // lowercase, with the leading dot
+
+	// LineComments and BlockComments are the comment delimiters of the
+	// language, block comments as start and end pairs.
+	LineComments  []string
+	BlockComments [][2]string
+	// SpacedComments requires line comments to start a line or follow a
+	// space, as in shell scripts where # may appear in words.
+	SpacedComments bool
+	// KeepComments are prefixes of comments carrying directives, never
+	// stripped.
+	KeepComments []string
+
+	// Quotes delimit string literals with backslash escapes, RawQuotes
+	// string literals without escapes. TripleQuotes tells whether tripled
+	// quotes delimit multi-line strings.
+	Quotes       string
+	RawQuotes    string
+	TripleQuotes bool
+	// CharLiterals tells whether single quotes delimit character literals,
+	// which Rust lifetimes must not be mistaken for.
+	CharLiterals bool
+	// RegexLiterals tells whether a slash starting an expression delimits a
+	// regular expression literal, as in JavaScript.
+	RegexLiterals bool
+	// MarkupExtensions are the extensions of files mixing code with markup
+	// text, as JSX does, where line comment delimiters may be part of the
+	// text. Line comments are kept in these files.
+	MarkupExtensions []string
+}
+
+var (
+	cComments   = [][2]string{{"/*", "*/"}}
+	slashes     = []string{"//"}
+	hash        = []string{"#"}
+	xmlComments = [][2]string{{"<!--", "-->"}}
+)
+
+// Languages is the registry of known languages.
+var Languages = []Language{
+	{ID: "go", Extensions: []string{".go"}, LineComments: slashes, BlockComments: cComments,
+		KeepComments: []string{"//go:", "// +build", "//line ", "//export "},
+		Quotes:       `"`, RawQuotes: "`", CharLiterals: true},
+	{ID: "python", Extensions: []string{".py", ".pyi"}, LineComments: hash,
+		Quotes: `"'`, TripleQuotes: true},
+	{ID: "typescript", Extensions: []string{".ts", ".tsx", ".mts", ".cts"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: "\"'`", RegexLiterals: true, MarkupExtensions: []string{".tsx"}},
+	{ID: "javascript", Extensions: []string{".js", ".jsx", ".mjs", ".cjs"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: "\"'`", RegexLiterals: true, MarkupExtensions: []string{".jsx"}},
+	{ID: "java", Extensions: []string{".java"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, TripleQuotes: true, CharLiterals: true},
+	{ID: "rust", Extensions: []string{".rs"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, CharLiterals: true},
+	{ID: "c", Extensions: []string{".c", ".h"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, CharLiterals: true},
+	{ID: "cpp", Extensions: []string{".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, CharLiterals: true},
+	{ID: "csharp", Extensions: []string{".cs"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, CharLiterals: true},
+	{ID: "kotlin", Extensions: []string{".kt", ".kts"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, TripleQuotes: true, CharLiterals: true},
+	{ID: "swift", Extensions: []string{".swift"}, LineComments: slashes, BlockComments: cComments,
+		Quotes: `"`, TripleQuotes: true},
+	{ID: "php", Extensions: []string{".php"}, LineComments: []string{"//", "#"}, BlockComments: cComments,
+		KeepComments: []string{"#["}, Quotes: `"'`},
+	{ID: "ruby", Extensions: []string{".rb"}, LineComments: hash, SpacedComments: true,
+		Quotes: `"'`},
+	{ID: "shell", Extensions: []string{".sh", ".bash", ".zsh"}, LineComments: hash, SpacedComments: true,
+		Quotes: `"`, RawQuotes: "'"},
+	{ID: "yaml", Extensions: []string{".yml", ".yaml"}, LineComments: hash, SpacedComments: true,
+		Quotes: `"`, RawQuotes: "'"},
+	{ID: "toml", Extensions: []string{".toml"}, LineComments: hash,
+		Quotes: `"`, RawQuotes: "'", TripleQuotes: true},
+	{ID: "sql", Extensions: []string{".sql"}, LineComments: []string{"--"}, BlockComments: cComments,
+		RawQuotes: `'"`},
+	{ID: "lua", Extensions: []string{".lua"}, LineComments: []string{"--"}, BlockComments: [][2]string{{"--[[", "]]"}},
+		Quotes: `"'`},
+	{ID: "css", Extensions: []string{".css"}, BlockComments: cComments,
+		Quotes: `"'`},
+	{ID: "scss", Extensions: []string{".scss", ".less"}, LineComments: slashes, BlockComments: cComments, SpacedComments: true,
+		Quotes: `"'`},
+	// Quotes in markup are mostly apostrophes in text, they are not tracked.
+	{ID: "html", Extensions: []string{".html", ".htm", ".xml", ".svg", ".vue"}, BlockComments: xmlComments},
+}
+
+// Detect returns the language of the file name from its extension.
+func Detect(name string) (Language, bool) {
+	ext := strings.ToLower(path.Ext(name))
+	for _, language := range Languages {
+		for _, e := range language.Extensions {
+			if e == ext {
+				return language, true
+			}
+		}
+	}
+	return Language{}, false
+}
+
+// Comment returns text as a comment of the language of the file name, or
+// text unchanged for languages missing from the registry.
+func Comment(name, text string) string {
+	language, _ := Detect(name)
+	switch {
+	case len(language.LineComments) > 0:
+		return language.LineComments[0] + " " + text
+	case len(language.BlockComments) > 0:
+		return language.BlockComments[0][0] + " " + text + " " + language.BlockComments[0][1]
+	}
+	return text
+}
@@ -0,0 +1,83 @@
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "main.go", expected: "go"},
+		{name: "pkg/tool.PY", expected: "python"},
+		{name: "web/App.tsx", expected: "typescript"},
+		{name: "index.mjs", expected: "javascript"},
+		{name: "Main.java", expected: "java"},
+		{name: "src/lib.rs", expected: "rust"},
+		{name: "README.md", expected: ""},
+		{name: "Makefile", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, ok := Detect(tt.name)
+			if ok != (tt.expected != "") || language.ID != tt.expected {
+				t.Errorf("Detect(%q) = %q, %v, want %q", tt.name, language.ID, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComment(t *testing.T) {
+	tests := map[string]string{
+		"main.go":    "// elided",
+		"tool.py":    "# elided",
+		"query.sql":  "-- elided",
+		"style.css":  "/* elided */",
+		"index.html": "<!-- elided -->",
+		"notes.txt":  "elided",
+	}
+	for name, expected := range tests {
+		if result := Comment(name, "elided"); result != expected {
+			t.Errorf("Comment(%q) = %q, want %q", name, result, expected)
+		}
+	}
+}
+
+func TestSummarizerFor(t *testing.T) {
+	custom := SummarizerFunc(func(name, content string) (string, error) {
+		return "custom", nil
+	})
+	overrides := map[string]Summarizer{"python": custom, "md": custom, "rust": nil, "kt": custom, "c": custom, "h": nil}
+
+	tests := []struct {
+		name     string
+		expected string // result of the summarizer, empty when there is none
+	}{
+		{name: "main.go", expected: "package main\n"},
+		{name: "tool.py", expected: "custom"},
+		{name: "README.md", expected: "custom"},
+		{name: "lib.rs"},
+		{name: "Main.kt", expected: "custom"},
+		{name: "util.h", expected: "custom"},
+		{name: "notes.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summarizer, ok := SummarizerFor(tt.name, overrides)
+			if ok != (tt.expected != "") {
+				t.Fatalf("SummarizerFor(%q) found = %v", tt.name, ok)
+			}
+			if !ok {
+				return
+			}
+			result, err := summarizer.Summarize(tt.name, "package main\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.expected {
+				t.Errorf("Summarize() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package lang
+
+import (
+	"bytes"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Minify strips the comments of content, trims trailing whitespace and
+// collapses blank lines, using the comment syntax of the language of the
+// file name. String literals are kept as they are. It reports false, leaving
+// content unchanged, for languages missing from the registry.
+func Minify(name, content string) (string, bool) {
+	language, ok := Detect(name)
+	if !ok {
+		return content, false
+	}
+	if slices.Contains(language.MarkupExtensions, strings.ToLower(path.Ext(name))) {
+		language.LineComments = nil
+	}
+	return language.minify(content), true
+}
+
+func (l Language) minify(content string) string {
+	m := minifier{language: l, content: content, out: make([]byte, 0, len(content))}
+
+	// A shebang is a comment only to the language.
+	if strings.HasPrefix(content, "#!") {
+		end := strings.IndexByte(content, '\n')
+		if end < 0 {
+			end = len(content)
+		}
+		m.out = append(m.out, content[:end]...)
+		m.pos = end
+	}
+
+	for m.pos < len(content) {
+		switch {
+		case content[m.pos] == '\n':
+			m.pos++
+			m.endLine()
+		case m.blockComment():
+		case m.lineComment():
+		case m.stringLiteral():
+		case m.regexLiteral():
+		case content[m.pos] == '\\' && m.pos+1 < len(content) && content[m.pos+1] != '\n':
+			// Escaped characters outside literals, such as \' in shell.
+			m.out = append(m.out, content[m.pos:m.pos+2]...)
+			m.pos += 2
+		default:
+			m.out = append(m.out, content[m.pos])
+			m.pos++
+		}
+	}
+	m.endLine()
+	return strings.TrimRight(string(m.out), "\n") + trailingNewline(content)
+}
+
+func trailingNewline(content string) string {
+	if strings.HasSuffix(content, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// minifier holds the state of Language.minify, which copies content to out
+// byte by byte, skipping comments.
+type minifier struct {
+	language Language
+	content  string
+	pos      int
+	out      []byte
+
+	lineStart      int  // offset of the current line in out
+	commentRemoved bool // a comment was removed from the current line
+	lastBlank      bool // the last line written is blank
+}
+
+// endLine finishes the current line of out: trailing whitespace is trimmed,
+// lines left empty by removed comments are dropped and blank lines are
+// collapsed.
+func (m *minifier) endLine() {
+	line := bytes.TrimRight(m.out[m.lineStart:], " \t\r")
+	blank := len(bytes.TrimSpace(line)) == 0
+
+	m.out = m.out[:m.lineStart]
+	switch {
+	case blank && m.commentRemoved:
+	case blank && (m.lastBlank || m.lineStart == 0):
+	case blank:
+		m.out = append(m.out, '\n')
+		m.lastBlank = true
+	default:
+		m.out = append(append(m.out, line...), '\n')
+		m.lastBlank = false
+	}
+	m.lineStart = len(m.out)
+	m.commentRemoved = false
+}
+
+func (m *minifier) blockComment() bool {
+	for _, delimiters := range m.language.BlockComments {
+		if !strings.HasPrefix(m.content[m.pos:], delimiters[0]) {
+			continue
+		}
+		end := strings.Index(m.content[m.pos+len(delimiters[0]):], delimiters[1])
+		if end < 0 {
+			end = len(m.content)
+		} else {
+			end += m.pos + len(delimiters[0]) + len(delimiters[1])
+		}
+		comment := m.content[m.pos:end]
+		m.pos = end
+		m.commentRemoved = true
+		if strings.Contains(comment, "\n") {
+			// Keep the code around the comment on separate lines.
+			m.endLine()
+			m.commentRemoved = true
+		} else if len(m.out) > m.lineStart && !isSpace(m.out[len(m.out)-1]) {
+			m.out = append(m.out, ' ')
+		}
+		return true
+	}
+	return false
+}
+
+func (m *minifier) lineComment() bool {
+	rest := m.content[m.pos:]
+	for _, prefix := range m.language.KeepComments {
+		if strings.HasPrefix(rest, prefix) {
+			return false
+		}
+	}
+	for _, delimiter := range m.language.LineComments {
+		if !strings.HasPrefix(rest, delimiter) {
+			continue
+		}
+		if m.language.SpacedComments && m.pos > 0 && !isSpace(m.content[m.pos-1]) && m.content[m.pos-1] != '\n' {
+			return false
+		}
+		end := strings.IndexByte(rest, '\n')
+		if end < 0 {
+			end = len(rest)
+		}
+		m.pos += end
+		m.commentRemoved = true
+		return true
+	}
+	return false
+}
+
+// stringLiteral copies the string or character literal at the current
+// position to out, newlines included.
+func (m *minifier) stringLiteral() bool {
+	c := m.content[m.pos]
+	raw := strings.IndexByte(m.language.RawQuotes, c) >= 0
+	if !raw && strings.IndexByte(m.language.Quotes, c) < 0 {
+		if c == '\'' && m.language.CharLiterals {
+			if loc := charLiteral.FindStringIndex(m.content[m.pos:]); loc != nil {
+				m.copyLiteral(m.pos + loc[1])
+				return true
+			}
+		}
+		return false
+	}
+
+	delimiter := string(c)
+	if m.language.TripleQuotes && strings.HasPrefix(m.content[m.pos:], strings.Repeat(delimiter, 3)) {
+		delimiter = strings.Repeat(delimiter, 3)
+	}
+	end := m.pos + len(delimiter)
+	for end < len(m.content) && !strings.HasPrefix(m.content[end:], delimiter) {
+		if !raw && m.content[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	m.copyLiteral(min(end+len(delimiter), len(m.content)))
+	return true
+}
+
+// regexKeywords are the keywords after which a slash starts a regular
+// expression rather than a division.
+var regexKeywords = []string{"return", "typeof", "instanceof", "in", "of", "new", "delete", "void", "throw", "case", "do", "else", "yield", "await"}
+
+// regexLiteral copies the regular expression literal at the current position
+// to out, comment delimiters in it included. A slash following an operand is
+// a division.
+func (m *minifier) regexLiteral() bool {
+	if !m.language.RegexLiterals || m.content[m.pos] != '/' {
+		return false
+	}
+	code := bytes.TrimRight(m.out, " \t\r\n")
+	if len(code) > 0 {
+		last := code[len(code)-1]
+		if last == ')' || last == ']' {
+			return false
+		}
+		if isWordByte(last) {
+			start := len(code)
+			for start > 0 && isWordByte(code[start-1]) {
+				start--
+			}
+			if !slices.Contains(regexKeywords, string(code[start:])) {
+				return false
+			}
+		}
+	}
+
+	inClass := false
+	for end := m.pos + 1; end < len(m.content); end++ {
+		switch m.content[end] {
+		case '\n':
+			return false
+		case '\\':
+			end++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				m.copyLiteral(end + 1)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// copyLiteral copies content up to end to out. Lines ending inside the
+// literal are part of it and left untouched.
+func (m *minifier) copyLiteral(end int) {
+	literal := m.content[m.pos:end]
+	m.out = append(m.out, literal...)
+	m.pos = end
+	if i := strings.LastIndexByte(literal, '\n'); i >= 0 {
+		m.lineStart = len(m.out) - (len(literal) - i - 1)
+		m.lastBlank = false
+		m.commentRemoved = false
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
@@ -0,0 +1,99 @@
+package lang
+
+import "testing"
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+		ok       bool
+	}{
+		{
+			name: "Go",
+			file: "main.go",
+			content: "//go:build linux\n\n// Package main does things.\npackage main   \n\n\n\n" +
+				"/* Block\n   comment */\nvar s = `raw // not a comment  \n\n/* kept */`\n" +
+				"var r = '\"' // quote rune\nvar u = \"http://example.com\" /* inline */ + \"\\\"//\"\n",
+			expected: "//go:build linux\n\npackage main\n\n" +
+				"var s = `raw // not a comment  \n\n/* kept */`\n" +
+				"var r = '\"'\nvar u = \"http://example.com\"  + \"\\\"//\"\n",
+			ok: true,
+		},
+		{
+			name:     "Code around a multi-line comment",
+			file:     "main.go",
+			content:  "x := 1 /* a\nb */ y := 2\n",
+			expected: "x := 1\n y := 2\n",
+			ok:       true,
+		},
+		{
+			name:     "Python",
+			file:     "tool.py",
+			content:  "#!/usr/bin/env python3\n# comment\ndef f():\n    \"\"\"Doc # not a comment.\n\n    More.\n    \"\"\"\n    return '#'  # trailing\n",
+			expected: "#!/usr/bin/env python3\ndef f():\n    \"\"\"Doc # not a comment.\n\n    More.\n    \"\"\"\n    return '#'\n",
+			ok:       true,
+		},
+		{
+			name:     "Shell",
+			file:     "run.sh",
+			content:  "echo a#b $# # comment\necho 'it'\\''s # kept'\n",
+			expected: "echo a#b $#\necho 'it'\\''s # kept'\n",
+			ok:       true,
+		},
+		{
+			name:     "Rust lifetimes",
+			file:     "lib.rs",
+			content:  "fn f<'a>(s: &'a str) -> char { // first\n    '\\'' /* quote */\n}\n",
+			expected: "fn f<'a>(s: &'a str) -> char {\n    '\\''\n}\n",
+			ok:       true,
+		},
+		{
+			name:     "JavaScript regular expressions",
+			file:     "app.js",
+			content:  "const re = /[/*]/;\nfoo();\n/* c */\nbar();\nif (/\\/\\//g.test(s)) return /a*/ // re\n",
+			expected: "const re = /[/*]/;\nfoo();\nbar();\nif (/\\/\\//g.test(s)) return /a*/\n",
+			ok:       true,
+		},
+		{
+			name:     "TypeScript divisions",
+			file:     "math.ts",
+			content:  "const half = total / 2; /* c */\nconst r = (a + b) / c[i] / d; // ratio\n",
+			expected: "const half = total / 2;\nconst r = (a + b) / c[i] / d;\n",
+			ok:       true,
+		},
+		{
+			name:     "JSX text",
+			file:     "Link.jsx",
+			content:  "/* Link renders a link. */\nconst el = <p>See http://x.com</p>;\n// kept\n",
+			expected: "const el = <p>See http://x.com</p>;\n// kept\n",
+			ok:       true,
+		},
+		{
+			name:     "PHP attributes",
+			file:     "Controller.php",
+			content:  "<?php\n# comment\n#[Route('/home')] // route\nfunction home() {}\n",
+			expected: "<?php\n#[Route('/home')]\nfunction home() {}\n",
+			ok:       true,
+		},
+		{
+			name:     "Unknown language",
+			file:     "notes.txt",
+			content:  "# title  \n\n\n// text\n",
+			expected: "# title  \n\n\n// text\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := Minify(tt.file, tt.content)
+			if ok != tt.ok {
+				t.Errorf("Minify() ok = %v, want %v", ok, tt.ok)
+			}
+			if result != tt.expected {
+				t.Errorf("Minify() =\n%q\nwant:\n%q", result, tt.expected)
+			}
+		})
+	}
+}
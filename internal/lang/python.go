@@ -0,0 +1,173 @@
+package lang
+
+import "strings"
+
+// summarizePython keeps the imports, decorators, class and function
+// signatures, docstrings and module or class level assignments of Python
+// source. Function bodies are replaced with an ellipsis.
+func summarizePython(name, content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	var out []string
+	skipIndent := -1 // lines indented deeper than this belong to an elided block
+	skippedBlank := false
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+		indent := indentation(line)
+		if skipIndent >= 0 {
+			if trimmed == "" || indent > skipIndent {
+				skippedBlank = skippedBlank || trimmed == ""
+				continue
+			}
+			skipIndent = -1
+			if skippedBlank {
+				out = appendBlank(out)
+			}
+			skippedBlank = false
+		}
+
+		switch {
+		case trimmed == "":
+			out = appendBlank(out)
+		case strings.HasPrefix(trimmed, "#"):
+			// Comments are left out, docstrings document the code.
+		case strings.HasPrefix(trimmed, "def ") || strings.HasPrefix(trimmed, "async def "):
+			end := pythonStatementEnd(lines, i)
+			out = append(out, lines[i:end+1]...)
+			i = end
+			if start, end, ok := pythonDocstring(lines, i+1); ok {
+				out = append(out, lines[start:end+1]...)
+				i = end
+			}
+			out = append(out, line[:len(line)-len(strings.TrimLeft(line, " \t"))]+"    ...")
+			skipIndent = indent
+		case strings.HasPrefix(trimmed, "class ") || strings.HasPrefix(trimmed, "@") ||
+			strings.HasPrefix(trimmed, "import ") || strings.HasPrefix(trimmed, "from "):
+			end := pythonStatementEnd(lines, i)
+			out = append(out, lines[i:end+1]...)
+			i = end
+		case isPythonString(trimmed):
+			if start, end, ok := pythonDocstring(lines, i); ok {
+				out = append(out, lines[start:end+1]...)
+				i = end
+			}
+		case isPythonAssignment(trimmed):
+			end := pythonStatementEnd(lines, i)
+			if end > i {
+				// Keep the target of long values only.
+				target, _, _ := strings.Cut(line, "=")
+				out = append(out, strings.TrimRight(target, " ")+" = ...")
+			} else {
+				out = append(out, line)
+			}
+			i = end
+		default:
+			// Other statements are left out, with the blocks they open.
+			end := pythonStatementEnd(lines, i)
+			if strings.HasSuffix(strings.TrimSpace(stripPythonComment(lines[end])), ":") {
+				skipIndent = indent
+			}
+			i = end
+		}
+	}
+	return joinLines(out), nil
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// pythonStatementEnd returns the index of the last line of the statement
+// starting at lines[start], following open brackets and line continuations.
+func pythonStatementEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		code := stripPythonComment(lines[i])
+		depth += strings.Count(code, "(") + strings.Count(code, "[") + strings.Count(code, "{")
+		depth -= strings.Count(code, ")") + strings.Count(code, "]") + strings.Count(code, "}")
+		if depth <= 0 && !strings.HasSuffix(strings.TrimSpace(code), "\\") {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// stripPythonComment removes a trailing comment and the content of string
+// literals from a line, so that brackets can be counted.
+func stripPythonComment(line string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return b.String()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isPythonString(trimmed string) bool {
+	trimmed = strings.TrimLeft(trimmed, "rRbBuUfF")
+	return strings.HasPrefix(trimmed, `"`) || strings.HasPrefix(trimmed, "'")
+}
+
+// isPythonAssignment reports whether the statement assigns or annotates a
+// name, such as "VERSION = 1" or "name: str".
+func isPythonAssignment(trimmed string) bool {
+	i := 0
+	for i < len(trimmed) && (trimmed[i] == '_' || trimmed[i] == '.' || isAlnum(trimmed[i])) {
+		i++
+	}
+	if i == 0 {
+		return false
+	}
+	rest := strings.TrimLeft(trimmed[i:], " ")
+	return strings.HasPrefix(rest, ":") && !strings.HasSuffix(rest, ":") ||
+		strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "==")
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// pythonDocstring returns the lines of the docstring starting at the first
+// non-blank line from start, if any.
+func pythonDocstring(lines []string, start int) (int, int, bool) {
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	if start >= len(lines) {
+		return 0, 0, false
+	}
+	trimmed := strings.TrimLeft(strings.TrimSpace(lines[start]), "rRbBuUfF")
+	for _, quote := range []string{`"""`, "'''"} {
+		if !strings.HasPrefix(trimmed, quote) {
+			continue
+		}
+		if strings.Count(trimmed, quote) >= 2 {
+			return start, start, true
+		}
+		for end := start + 1; end < len(lines); end++ {
+			if strings.Contains(lines[end], quote) {
+				return start, end, true
+			}
+		}
+		return start, len(lines) - 1, true
+	}
+	if isPythonString(trimmed) {
+		return start, start, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,93 @@
+package lang
+
+import "testing"
+
+func TestSummarizePython(t *testing.T) {
+	source := `#!/usr/bin/env python3
+"""Scanner module."""
+import os
+from typing import (
+    List,
+    Optional,
+)
+
+VERSION = "1.0"
+DEFAULTS = {
+    "root": ".",
+}
+
+
+@dataclass
+class Scanner(Base):
+    """A scanner."""
+
+    root: str
+    limit: int = 10
+
+    def __init__(self, root: str) -> None:
+        # comment
+        self.root = root
+
+    async def run(
+        self,
+        pattern: Optional[str] = None,
+    ) -> List[str]:
+        """Run the scan.
+
+        Returns the paths.
+        """
+        def inner():
+            pass
+
+        return [p for p in os.listdir(self.root)]
+
+
+def main():
+    Scanner(".").run()
+
+
+if __name__ == "__main__":
+    main()
+`
+	expected := `"""Scanner module."""
+import os
+from typing import (
+    List,
+    Optional,
+)
+
+VERSION = "1.0"
+DEFAULTS = ...
+
+@dataclass
+class Scanner(Base):
+    """A scanner."""
+
+    root: str
+    limit: int = 10
+
+    def __init__(self, root: str) -> None:
+        ...
+
+    async def run(
+        self,
+        pattern: Optional[str] = None,
+    ) -> List[str]:
+        """Run the scan.
+
+        Returns the paths.
+        """
+        ...
+
+def main():
+    ...
+`
+
+	summary, err := summarizePython("scanner.py", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != expected {
+		t.Errorf("summarizePython() =\n%s\nwant:\n%s", summary, expected)
+	}
+}
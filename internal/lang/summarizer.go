@@ -0,0 +1,68 @@
+package lang
+
+import (
+	"path"
+	"strings"
+)
+
+// Summarizer turns the content of a source file into a compressed
+// representation of it, such as its signatures and exported symbols.
+type Summarizer interface {
+	Summarize(name, content string) (string, error)
+}
+
+// SummarizerFunc adapts a function to the Summarizer interface.
+type SummarizerFunc func(name, content string) (string, error)
+
+func (f SummarizerFunc) Summarize(name, content string) (string, error) {
+	return f(name, content)
+}
+
+// summarizers holds the built-in summarizers by language ID.
+var summarizers = map[string]Summarizer{
+	"go":         SummarizerFunc(summarizeGo),
+	"python":     SummarizerFunc(summarizePython),
+	"typescript": typescriptSummarizer,
+	"javascript": javascriptSummarizer,
+	"java":       javaSummarizer,
+	"rust":       rustSummarizer,
+}
+
+// SummarizerFor returns the summarizer for the language of the file name.
+// Summarizers in overrides take precedence over the built-in ones, keyed by
+// the ID of the language in the registry or by the extension of the file
+// without the dot, the ID being looked up first.
+func SummarizerFor(name string, overrides map[string]Summarizer) (Summarizer, bool) {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	id := ext
+	if language, ok := Detect(name); ok {
+		id = language.ID
+	}
+	for _, key := range []string{id, ext} {
+		if summarizer, ok := overrides[key]; ok {
+			return summarizer, summarizer != nil
+		}
+	}
+	summarizer, ok := summarizers[id]
+	return summarizer, ok
+}
+
+// appendBlank appends an empty line to lines, unless it would follow
+// another one or start the output.
+func appendBlank(lines []string) []string {
+	if len(lines) == 0 || lines[len(lines)-1] == "" {
+		return lines
+	}
+	return append(lines, "")
+}
+
+// joinLines joins the lines of a summary, ending it with a single newline.
+func joinLines(lines []string) string {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -12,11 +13,16 @@ type Logger struct {
 var defaultLogger *Logger
 
 func init() {
+	defaultLogger = New(os.Stdout, slog.LevelInfo)
+}
+
+// New creates a logger writing JSON records of at least level to w.
+func New(w io.Writer, level slog.Level) *Logger {
 	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: level,
 	}
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	defaultLogger = &Logger{slog.New(handler)}
+	handler := slog.NewJSONHandler(w, opts)
+	return &Logger{slog.New(handler)}
 }
 
 func Default() *Logger {
@@ -24,9 +30,5 @@ func Default() *Logger {
 }
 
 func (l *Logger) WithLevel(level slog.Level) *Logger {
-	opts := &slog.HandlerOptions{
-		Level: level,
-	}
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	return &Logger{slog.New(handler)}
+	return New(os.Stdout, level)
 }
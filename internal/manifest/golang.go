@@ -0,0 +1,111 @@
+package manifest
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// parseGoMod reads the module path, Go and toolchain versions and direct
+// requirements of a go.mod file.
+func parseGoMod(content string) (Manifest, error) {
+	var manifest Manifest
+	inRequire := false
+	lines := bufio.NewScanner(strings.NewReader(content))
+	for lines.Scan() {
+		line, comment, _ := strings.Cut(lines.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case inRequire:
+			fields = append([]string{"require"}, fields...)
+		case len(fields) == 2 && fields[0] == "require" && fields[1] == "(":
+			inRequire = true
+			continue
+		}
+
+		switch {
+		case fields[0] == "module" && len(fields) == 2:
+			manifest.Name = goModString(fields[1])
+		case fields[0] == "go" && len(fields) == 2:
+			manifest.Versions = append(manifest.Versions, "go "+fields[1])
+		case fields[0] == "toolchain" && len(fields) == 2:
+			manifest.Versions = append(manifest.Versions, "toolchain "+fields[1])
+		case fields[0] == "require" && len(fields) == 3 && strings.TrimSpace(comment) != "indirect":
+			manifest.Dependencies = append(manifest.Dependencies, withVersion(goModString(fields[1]), fields[2]))
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return Manifest{}, err
+	}
+	if manifest.Name == "" {
+		return Manifest{}, errors.New("no module directive")
+	}
+	return manifest, nil
+}
+
+func goModString(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return value
+}
+
+// parseGoreleaser reads the project name and the binaries built by a
+// GoReleaser configuration. The YAML is read line by line, only the top-level
+// project_name and the binary and main keys of the builds are used.
+func parseGoreleaser(content string) (Manifest, error) {
+	var manifest Manifest
+	var binary, main string
+	flush := func() {
+		switch {
+		case binary != "" && main != "":
+			manifest.Entrypoints = append(manifest.Entrypoints, binary+" ("+main+")")
+		case binary != "" || main != "":
+			manifest.Entrypoints = append(manifest.Entrypoints, binary+main)
+		}
+		binary, main = "", ""
+	}
+
+	inBuilds, itemIndent := false, -1
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			flush()
+			key, value, _ := strings.Cut(trimmed, ":")
+			inBuilds, itemIndent = key == "builds", -1
+			if key == "project_name" {
+				manifest.Name = unquote(value)
+			}
+			continue
+		}
+		if !inBuilds {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") && (itemIndent < 0 || indent == itemIndent) {
+			flush()
+			itemIndent = indent
+			trimmed = strings.TrimSpace(trimmed[2:])
+		} else if indent != itemIndent+2 {
+			continue
+		}
+		key, value, _ := strings.Cut(trimmed, ":")
+		switch key {
+		case "binary":
+			binary = unquote(value)
+		case "main":
+			main = unquote(value)
+		}
+	}
+	flush()
+	return manifest, nil
+}
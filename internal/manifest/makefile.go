@@ -0,0 +1,30 @@
+package manifest
+
+import (
+	"regexp"
+	"strings"
+)
+
+// makeRule matches the targets of a rule, leaving out variable assignments.
+var makeRule = regexp.MustCompile(`^([^\s:=#][^:=#]*?)\s*::?(?:[^=]|$)`)
+
+// parseMakefile lists the explicit targets of a Makefile, in order. Special
+// targets such as .PHONY and pattern rules are left out.
+func parseMakefile(content string) (Manifest, error) {
+	var manifest Manifest
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		match := makeRule.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for _, target := range strings.Fields(match[1]) {
+			if seen[target] || strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%$") {
+				continue
+			}
+			seen[target] = true
+			manifest.Scripts = append(manifest.Scripts, target)
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,101 @@
+// Package manifest extracts an overview of a project from its manifests and
+// build files: its name, required versions, dependencies, scripts and
+// entrypoints.
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Manifest summarizes a project manifest or build file.
+type Manifest struct {
+	Path         string   // slash-separated, relative to the root
+	Kind         string   // format of the manifest, named after its usual file name
+	Name         string   // module, package or project name
+	Versions     []string // required language and tool versions, e.g. "go 1.22"
+	Dependencies []string // direct dependencies and their version
+	Scripts      []string // scripts, or targets of a Makefile
+	Entrypoints  []string // binaries, main files and classes
+}
+
+type parser func(content string) (Manifest, error)
+
+// kinds maps the file names of known manifests to their kind.
+var kinds = map[string]string{
+	"go.mod":           "go.mod",
+	"package.json":     "package.json",
+	"pyproject.toml":   "pyproject.toml",
+	"Cargo.toml":       "Cargo.toml",
+	"pom.xml":          "pom.xml",
+	"Makefile":         "Makefile",
+	"makefile":         "Makefile",
+	"GNUmakefile":      "Makefile",
+	".goreleaser.yaml": ".goreleaser.yaml",
+	".goreleaser.yml":  ".goreleaser.yaml",
+}
+
+// order ranks the kinds of manifests: language manifests, which name the
+// project, come before build files.
+var order = []string{"go.mod", "package.json", "pyproject.toml", "Cargo.toml", "pom.xml", "Makefile", ".goreleaser.yaml"}
+
+var parsers = map[string]parser{
+	"go.mod":           parseGoMod,
+	"package.json":     parsePackageJSON,
+	"pyproject.toml":   parsePyproject,
+	"Cargo.toml":       parseCargo,
+	"pom.xml":          parsePom,
+	"Makefile":         parseMakefile,
+	".goreleaser.yaml": parseGoreleaser,
+}
+
+// Detect returns the kind of the manifest name, based on its file name.
+func Detect(name string) (string, bool) {
+	kind, ok := kinds[path.Base(name)]
+	return kind, ok
+}
+
+// Parse summarizes the manifest name holding content.
+func Parse(name, content string) (Manifest, error) {
+	kind, ok := Detect(name)
+	if !ok {
+		return Manifest{}, fmt.Errorf("unknown manifest %s", name)
+	}
+	manifest, err := parsers[kind](content)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error parsing %s: %w", name, err)
+	}
+	manifest.Path, manifest.Kind = name, kind
+	return manifest, nil
+}
+
+// Sort orders manifests by kind, language manifests first, then by path.
+func Sort(manifests []Manifest) {
+	rank := func(m Manifest) int { return slices.Index(order, m.Kind) }
+	sort.SliceStable(manifests, func(i, j int) bool {
+		if rank(manifests[i]) != rank(manifests[j]) {
+			return rank(manifests[i]) < rank(manifests[j])
+		}
+		return manifests[i].Path < manifests[j].Path
+	})
+}
+
+// unquote strips the quotes around a YAML or Makefile value.
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// withVersion joins a dependency name and its version, when known.
+func withVersion(name, version string) string {
+	if version == "" || version == "*" {
+		return name
+	}
+	return name + " " + version
+}
@@ -0,0 +1,293 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected Manifest
+	}{
+		{
+			name: "go.mod",
+			content: `// Example module.
+module "example.com/app"
+
+go 1.22
+
+toolchain go1.22.4
+
+require github.com/spf13/cobra v1.9.1
+
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/sys v0.20.0 // indirect
+)
+`,
+			expected: Manifest{
+				Name:         "example.com/app",
+				Versions:     []string{"go 1.22", "toolchain go1.22.4"},
+				Dependencies: []string{"github.com/spf13/cobra v1.9.1", "github.com/google/uuid v1.6.0"},
+			},
+		},
+		{
+			name: "web/package.json",
+			content: `{
+  "name": "web",
+  "main": "dist/index.js",
+  "bin": {"web-cli": "bin/cli.js"},
+  "engines": {"node": ">=18", "npm": ">=9"},
+  "scripts": {"test": "vitest", "build": "tsc -p ."},
+  "dependencies": {"react": "^18.2.0"},
+  "devDependencies": {"vitest": "^1.0.0"}
+}`,
+			expected: Manifest{
+				Name:         "web",
+				Versions:     []string{"node >=18", "npm >=9"},
+				Dependencies: []string{"react ^18.2.0", "vitest ^1.0.0 (dev)"},
+				Scripts:      []string{"build", "test"},
+				Entrypoints:  []string{"web-cli (bin/cli.js)", "dist/index.js"},
+			},
+		},
+		{
+			name:    "package.json",
+			content: `{"name": "tool", "bin": "cli.js"}`,
+			expected: Manifest{
+				Name:        "tool",
+				Entrypoints: []string{"tool (cli.js)"},
+			},
+		},
+		{
+			name: "pyproject.toml",
+			content: `[build-system]
+requires = ["hatchling"]
+
+[project]
+name = "tool"
+description = """
+A tool, with [brackets] in its description.
+"""
+requires-python = ">=3.10" # comment
+dependencies = [
+    "requests>=2.31",  # HTTP
+    'click',
+]
+
+[project.scripts]
+tool = "tool.cli:main"
+
+[tool.pdm.scripts]
+lint = "ruff check ."
+`,
+			expected: Manifest{
+				Name:         "tool",
+				Versions:     []string{"python >=3.10"},
+				Dependencies: []string{"requests>=2.31", "click"},
+				Scripts:      []string{"lint"},
+				Entrypoints:  []string{"tool (tool.cli:main)"},
+			},
+		},
+		{
+			name: "pyproject.toml",
+			content: `[tool.poetry]
+name = "legacy"
+
+[tool.poetry.dependencies]
+python = "^3.9"
+django = "^4.2"
+celery = { version = "^5.3", extras = ["redis"] }
+
+[tool.poetry.scripts]
+serve = "legacy.server:run"
+`,
+			expected: Manifest{
+				Name:         "legacy",
+				Versions:     []string{"python ^3.9"},
+				Dependencies: []string{"celery ^5.3", "django ^4.2"},
+				Entrypoints:  []string{"serve (legacy.server:run)"},
+			},
+		},
+		{
+			name: "Cargo.toml",
+			content: `[package]
+name = "peek"
+edition = "2021"
+rust-version = "1.70"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+anyhow = "1"
+local = { path = "../local" }
+shared.workspace = true
+
+[dependencies.tokio]
+version = "1.37"
+features = ["full"]
+
+[dev-dependencies]
+insta = "1.39"
+
+[[bin]]
+name = "peek"
+path = "src/main.rs"
+
+[[bin]]
+name = "peekd"
+`,
+			expected: Manifest{
+				Name:         "peek",
+				Versions:     []string{"rust 1.70", "edition 2021"},
+				Dependencies: []string{"anyhow 1", "local", "serde 1.0", "shared", "tokio 1.37", "insta 1.39 (dev)"},
+				Entrypoints:  []string{"peek (src/main.rs)", "peekd"},
+			},
+		},
+		{
+			name: "pom.xml",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+  </parent>
+  <artifactId>service</artifactId>
+  <properties>
+    <maven.compiler.source>17</maven.compiler.source>
+    <java.version>21</java.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.13</version>
+    </dependency>
+    <dependency>
+      <groupId>org.junit.jupiter</groupId>
+      <artifactId>junit-jupiter</artifactId>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+  <build>
+    <plugins>
+      <plugin>
+        <configuration>
+          <archive><manifest><mainClass>com.example.Main</mainClass></manifest></archive>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`,
+			expected: Manifest{
+				Name:         "com.example:service",
+				Versions:     []string{"java 21"},
+				Dependencies: []string{"org.slf4j:slf4j-api 2.0.13", "org.junit.jupiter:junit-jupiter (test)"},
+				Entrypoints:  []string{"com.example.Main"},
+			},
+		},
+		{
+			name: "Makefile",
+			content: `.PHONY: build test
+BINARY := app
+VERSION = $(shell git describe)
+
+build: deps
+	go build -o $(BINARY) ./cmd/app
+
+test lint:: build
+	go test ./...
+
+%.o: %.c
+	cc -c $<
+
+$(BINARY): build
+deps:
+`,
+			expected: Manifest{
+				Scripts: []string{"build", "test", "lint", "deps"},
+			},
+		},
+		{
+			name: ".goreleaser.yml",
+			content: `version: 2
+project_name: "app"
+
+builds:
+  # The CLI.
+  - id: app
+    binary: app
+    main: ./cmd/app
+    hooks:
+      post:
+        - main: ignored
+  - main: ./cmd/daemon
+
+nfpms:
+  - id: app
+    dependencies:
+      - git
+`,
+			expected: Manifest{
+				Name:        "app",
+				Entrypoints: []string{"app (./cmd/app)", "./cmd/daemon"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := Parse(tt.name, tt.content)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			kind, _ := Detect(tt.name)
+			tt.expected.Path, tt.expected.Kind = tt.name, kind
+			if !reflect.DeepEqual(manifest, tt.expected) {
+				t.Errorf("Parse() = %#v, want %#v", manifest, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "README.md", content: "# Readme\n"},
+		{name: "go.mod", content: "go 1.22\n"},
+		{name: "package.json", content: "{"},
+		{name: "pom.xml", content: "<project></project>"},
+		{name: "Cargo.toml", content: "[package\nname = \"x\"\n"},
+		{name: "pyproject.toml", content: "[project]\nname = \"x\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.name, tt.content); err == nil {
+				t.Errorf("Parse(%q) expected an error", tt.content)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	manifests := []Manifest{
+		{Path: "Makefile", Kind: "Makefile"},
+		{Path: "b/go.mod", Kind: "go.mod"},
+		{Path: "package.json", Kind: "package.json"},
+		{Path: "a/go.mod", Kind: "go.mod"},
+	}
+	Sort(manifests)
+
+	var paths []string
+	for _, m := range manifests {
+		paths = append(paths, m.Path)
+	}
+	expected := []string{"a/go.mod", "b/go.mod", "package.json", "Makefile"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Sort() = %v, want %v", paths, expected)
+	}
+}
@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+type pomProject struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Parent     struct {
+		GroupID string `xml:"groupId"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies []struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+		Scope      string `xml:"scope"`
+	} `xml:"dependencies>dependency"`
+}
+
+// javaVersionProperties are the properties giving the Java version of a
+// Maven project, by precedence.
+var javaVersionProperties = []string{"maven.compiler.release", "java.version", "maven.compiler.source"}
+
+// parsePom reads the coordinates, Java version, dependencies and main
+// classes of a Maven pom.xml.
+func parsePom(content string) (Manifest, error) {
+	var project pomProject
+	if err := xml.Unmarshal([]byte(content), &project); err != nil {
+		return Manifest{}, err
+	}
+	if project.ArtifactID == "" {
+		return Manifest{}, errors.New("no artifactId")
+	}
+
+	groupID := project.GroupID
+	if groupID == "" {
+		groupID = project.Parent.GroupID
+	}
+	manifest := Manifest{Name: project.ArtifactID}
+	if groupID != "" {
+		manifest.Name = groupID + ":" + project.ArtifactID
+	}
+
+	properties := make(map[string]string)
+	for _, entry := range project.Properties.Entries {
+		properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	for _, property := range javaVersionProperties {
+		if version := properties[property]; version != "" {
+			manifest.Versions = append(manifest.Versions, "java "+version)
+			break
+		}
+	}
+
+	for _, dependency := range project.Dependencies {
+		name := withVersion(dependency.GroupID+":"+dependency.ArtifactID, dependency.Version)
+		if dependency.Scope != "" && dependency.Scope != "compile" {
+			name += " (" + dependency.Scope + ")"
+		}
+		manifest.Dependencies = append(manifest.Dependencies, name)
+	}
+
+	mainClasses, err := pomMainClasses(content)
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest.Entrypoints = mainClasses
+	return manifest, nil
+}
+
+// pomMainClasses returns the mainClass values set anywhere in the pom, as
+// plugins configure them at various depths.
+func pomMainClasses(content string) ([]string, error) {
+	var classes []string
+	seen := make(map[string]bool)
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	inMainClass := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return classes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			inMainClass = token.Name.Local == "mainClass"
+		case xml.EndElement:
+			inMainClass = false
+		case xml.CharData:
+			class := strings.TrimSpace(string(token))
+			if inMainClass && class != "" && !seen[class] {
+				seen[class] = true
+				classes = append(classes, class)
+			}
+		}
+	}
+}
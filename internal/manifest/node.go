@@ -0,0 +1,69 @@
+package manifest
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+type packageJSON struct {
+	Name            string            `json:"name"`
+	Main            string            `json:"main"`
+	Module          string            `json:"module"`
+	Bin             json.RawMessage   `json:"bin"`
+	Engines         map[string]string `json:"engines"`
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+// parsePackageJSON reads the name, engines, dependencies, scripts and
+// entrypoints of an npm package.json.
+func parsePackageJSON(content string) (Manifest, error) {
+	var pkg packageJSON
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{Name: pkg.Name}
+	for _, engine := range sortedKeys(pkg.Engines) {
+		manifest.Versions = append(manifest.Versions, withVersion(engine, pkg.Engines[engine]))
+	}
+	for _, name := range sortedKeys(pkg.Dependencies) {
+		manifest.Dependencies = append(manifest.Dependencies, withVersion(name, pkg.Dependencies[name]))
+	}
+	for _, name := range sortedKeys(pkg.DevDependencies) {
+		manifest.Dependencies = append(manifest.Dependencies, withVersion(name, pkg.DevDependencies[name])+" (dev)")
+	}
+	manifest.Scripts = sortedKeys(pkg.Scripts)
+
+	// bin is either the path of the single binary of the package, named
+	// after it, or maps the names of its binaries to their path.
+	var bin string
+	var bins map[string]string
+	switch {
+	case json.Unmarshal(pkg.Bin, &bin) == nil && bin != "":
+		manifest.Entrypoints = append(manifest.Entrypoints, pkg.Name+" ("+bin+")")
+	case json.Unmarshal(pkg.Bin, &bins) == nil:
+		for _, name := range sortedKeys(bins) {
+			manifest.Entrypoints = append(manifest.Entrypoints, name+" ("+bins[name]+")")
+		}
+	}
+	for _, entry := range []string{pkg.Main, pkg.Module} {
+		if entry != "" {
+			manifest.Entrypoints = append(manifest.Entrypoints, entry)
+		}
+	}
+	return manifest, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
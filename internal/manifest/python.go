@@ -0,0 +1,41 @@
+package manifest
+
+// parsePyproject reads the name, Python version, dependencies, scripts and
+// console entrypoints of a pyproject.toml, from its standard project table
+// or from the Poetry tables.
+func parsePyproject(content string) (Manifest, error) {
+	document, err := parseTOML(content)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	project := document.table("project")
+	poetry := document.table("tool.poetry")
+	poetryDependencies := document.table("tool.poetry.dependencies")
+
+	manifest := Manifest{Name: tomlString(project["name"])}
+	if manifest.Name == "" {
+		manifest.Name = tomlString(poetry["name"])
+	}
+	if version := tomlString(project["requires-python"]); version != "" {
+		manifest.Versions = append(manifest.Versions, "python "+version)
+	} else if version := tomlString(poetryDependencies["python"]); version != "" {
+		manifest.Versions = append(manifest.Versions, "python "+version)
+	}
+
+	manifest.Dependencies = tomlStrings(project["dependencies"])
+	manifest.Dependencies = append(manifest.Dependencies, document.dependencies("tool.poetry.dependencies", "python")...)
+
+	// PDM and Hatch define scripts to run in the project environment.
+	for _, table := range []string{"tool.pdm.scripts", "tool.hatch.envs.default.scripts"} {
+		manifest.Scripts = append(manifest.Scripts, sortedKeys(document.table(table))...)
+	}
+
+	for _, table := range []string{"project.scripts", "project.gui-scripts", "tool.poetry.scripts"} {
+		scripts := document.table(table)
+		for _, name := range sortedKeys(scripts) {
+			manifest.Entrypoints = append(manifest.Entrypoints, name+" ("+tomlString(scripts[name])+")")
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,35 @@
+package manifest
+
+// parseCargo reads the package name, Rust version and edition, dependencies
+// and binaries of a Cargo.toml.
+func parseCargo(content string) (Manifest, error) {
+	document, err := parseTOML(content)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	pkg := document.table("package")
+	manifest := Manifest{Name: tomlString(pkg["name"])}
+	if version := tomlString(pkg["rust-version"]); version != "" {
+		manifest.Versions = append(manifest.Versions, "rust "+version)
+	}
+	if edition := tomlString(pkg["edition"]); edition != "" {
+		manifest.Versions = append(manifest.Versions, "edition "+edition)
+	}
+	manifest.Dependencies = document.dependencies("dependencies", "")
+	for _, dependency := range document.dependencies("dev-dependencies", "") {
+		manifest.Dependencies = append(manifest.Dependencies, dependency+" (dev)")
+	}
+	for _, bin := range document.tables("bin") {
+		name, path := tomlString(bin["name"]), tomlString(bin["path"])
+		if path != "" {
+			name += " (" + path + ")"
+		}
+		manifest.Entrypoints = append(manifest.Entrypoints, name)
+	}
+	if members := tomlStrings(document.table("workspace")["members"]); manifest.Name == "" && len(members) > 0 {
+		manifest.Name = "workspace"
+		manifest.Entrypoints = append(manifest.Entrypoints, members...)
+	}
+	return manifest, nil
+}
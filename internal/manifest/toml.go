@@ -0,0 +1,324 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tomlTable is a table of a TOML document. Its values are strings, arrays
+// ([]any) and inline tables (map[string]any), keyed by their dotted key
+// relative to the table. Numbers, booleans and dates are kept as written.
+type tomlTable struct {
+	name   string
+	values map[string]any
+}
+
+// tomlDocument holds the tables of a TOML document in order, starting with
+// the root table. Arrays of tables appear once per element.
+type tomlDocument []*tomlTable
+
+// table returns the values of the table name, empty when it is missing.
+func (d tomlDocument) table(name string) map[string]any {
+	for _, table := range d {
+		if table.name == name {
+			return table.values
+		}
+	}
+	return nil
+}
+
+// tables returns the values of each element of the array of tables name.
+func (d tomlDocument) tables(name string) []map[string]any {
+	var tables []map[string]any
+	for _, table := range d {
+		if table.name == name {
+			tables = append(tables, table.values)
+		}
+	}
+	return tables
+}
+
+// parseTOML reads the subset of TOML used by manifests: tables, arrays of
+// tables, and key/value pairs whose values may span several lines.
+func parseTOML(content string) (tomlDocument, error) {
+	current := &tomlTable{values: make(map[string]any)}
+	document := tomlDocument{current}
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			closing := "]"
+			if strings.HasPrefix(line, "[[") {
+				closing = "]]"
+			}
+			end := strings.Index(line, closing)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated table header", i+1)
+			}
+			current = &tomlTable{name: tomlKey(line[len(closing):end]), values: make(map[string]any)}
+			document = append(document, current)
+			continue
+		}
+
+		start := i
+		for !tomlComplete(line) && i+1 < len(lines) {
+			i++
+			line += "\n" + lines[i]
+		}
+		p := &tomlParser{s: line}
+		key, value, err := p.keyValue()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start+1, err)
+		}
+		current.values[key] = value
+	}
+	return document, nil
+}
+
+// tomlComplete reports whether s holds complete values: no string is left
+// open and all brackets are closed.
+func tomlComplete(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], "'''"):
+			end := strings.Index(s[i+3:], s[i:i+3])
+			if end < 0 {
+				return false
+			}
+			i += end + 5
+		case s[i] == '"' || s[i] == '\'':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote && s[i] != '\n'; i++ {
+				if quote == '"' && s[i] == '\\' {
+					i++
+				}
+			}
+		case s[i] == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case s[i] == '[' || s[i] == '{':
+			depth++
+		case s[i] == ']' || s[i] == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// tomlKey returns the dotted key raw, its parts unquoted.
+func tomlKey(raw string) string {
+	var parts []string
+	var part strings.Builder
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return strings.Join(append(parts, strings.TrimSpace(part.String())), ".")
+}
+
+type tomlParser struct {
+	s   string
+	pos int
+}
+
+func (p *tomlParser) keyValue() (string, any, error) {
+	start := p.pos
+	var quote byte
+	for ; p.pos < len(p.s); p.pos++ {
+		c := p.s[p.pos]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+		} else if c == '"' || c == '\'' {
+			quote = c
+		} else if c == '=' {
+			break
+		}
+	}
+	if p.pos == len(p.s) {
+		return "", nil, fmt.Errorf("expected key = value")
+	}
+	key := tomlKey(p.s[start:p.pos])
+	p.pos++
+	value, err := p.value()
+	return key, value, err
+}
+
+// skip moves past whitespace, newlines and comments.
+func (p *tomlParser) skip() {
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) value() (any, error) {
+	p.skip()
+	if p.pos == len(p.s) {
+		return nil, fmt.Errorf("missing value")
+	}
+	rest := p.s[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''"):
+		end := strings.Index(rest[3:], rest[:3])
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		p.pos += end + 6
+		return strings.TrimPrefix(rest[3:3+end], "\n"), nil
+	case rest[0] == '"':
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		p.pos += end + 1
+		if value, err := strconv.Unquote(rest[:end+1]); err == nil {
+			return value, nil
+		}
+		return rest[1:end], nil
+	case rest[0] == '\'':
+		end := strings.IndexByte(rest[1:], '\'')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		p.pos += end + 2
+		return rest[1 : end+1], nil
+	case rest[0] == '[':
+		p.pos++
+		var values []any
+		for {
+			p.skip()
+			if p.pos < len(p.s) && p.s[p.pos] == ']' {
+				p.pos++
+				return values, nil
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			p.skip()
+			if p.pos < len(p.s) && p.s[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.s) || p.s[p.pos] != ']' {
+				return nil, fmt.Errorf("unterminated array")
+			}
+		}
+	case rest[0] == '{':
+		p.pos++
+		values := make(map[string]any)
+		for {
+			p.skip()
+			if p.pos < len(p.s) && p.s[p.pos] == '}' {
+				p.pos++
+				return values, nil
+			}
+			key, value, err := p.keyValue()
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+			p.skip()
+			if p.pos < len(p.s) && p.s[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.s) || p.s[p.pos] != '}' {
+				return nil, fmt.Errorf("unterminated inline table")
+			}
+		}
+	}
+	end := strings.IndexAny(rest, ",]}# \t\r\n")
+	if end < 0 {
+		end = len(rest)
+	}
+	p.pos += end
+	return rest[:end], nil
+}
+
+// tomlString returns value when it is a string.
+func tomlString(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+// tomlStrings returns the strings of the array value.
+func tomlStrings(value any) []string {
+	values, _ := value.([]any)
+	var result []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// dependencies lists the dependencies declared in the table name, as
+// name = version, as an inline table with a version key, with dotted keys or
+// in a sub-table of their own. The key skip is left out.
+func (d tomlDocument) dependencies(name, skip string) []string {
+	versions := make(map[string]string)
+	for key, value := range d.table(name) {
+		dependency, field, dotted := strings.Cut(key, ".")
+		switch {
+		case !dotted:
+			versions[dependency] = tomlString(value)
+			if spec, ok := value.(map[string]any); ok {
+				versions[dependency] = tomlString(spec["version"])
+			}
+		case field == "version":
+			versions[dependency] = tomlString(value)
+		default:
+			if _, ok := versions[dependency]; !ok {
+				versions[dependency] = ""
+			}
+		}
+	}
+	for _, table := range d {
+		if dependency, ok := strings.CutPrefix(table.name, name+"."); ok {
+			versions[dependency] = tomlString(table.values["version"])
+		}
+	}
+
+	var dependencies []string
+	for _, dependency := range sortedKeys(versions) {
+		if dependency != skip {
+			dependencies = append(dependencies, withVersion(dependency, versions[dependency]))
+		}
+	}
+	return dependencies
+}
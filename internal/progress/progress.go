@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nouuu/gopeek/internal/scanner"
+)
+
+const (
+	refreshInterval = 100 * time.Millisecond
+	lineWidth       = 100
+)
+
+// Printer draws a single progress line, redrawn in place as a scan advances.
+type Printer struct {
+	w     io.Writer
+	now   func() time.Time
+	start time.Time
+	last  time.Time
+	drawn bool
+}
+
+func New(w io.Writer) *Printer {
+	return &Printer{w: w, now: time.Now}
+}
+
+// IsTerminal reports whether f is attached to a terminal, the only case where
+// redrawing a line in place makes sense.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update redraws the progress line, at most once per refresh interval.
+func (p *Printer) Update(progress scanner.Progress) {
+	now := p.now()
+	if p.start.IsZero() {
+		p.start = now
+	}
+	if p.drawn && now.Sub(p.last) < refreshInterval {
+		return
+	}
+	p.last = now
+	p.drawn = true
+	fmt.Fprintf(p.w, "\r\033[K%s", p.line(progress, now.Sub(p.start)))
+}
+
+// Done clears the progress line.
+func (p *Printer) Done() {
+	if p.drawn {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+func (p *Printer) line(progress scanner.Progress, elapsed time.Duration) string {
+	files := fmt.Sprintf("%d files", progress.Files)
+	if progress.Total > 0 {
+		files = fmt.Sprintf("%d/%d files", progress.Files, progress.Total)
+	}
+	parts := []string{files, scanner.FormatBytes(progress.Bytes)}
+
+	if eta, ok := estimate(progress, elapsed); ok {
+		parts = append(parts, "ETA "+eta.Round(time.Second).String())
+	}
+
+	line := "Scanning " + strings.Join(parts, " · ") + " · "
+	return line + truncatePath(progress.Path, lineWidth-len([]rune(line)))
+}
+
+// estimate extrapolates the remaining time from the files visited so far,
+// once the total number of files is known.
+func estimate(progress scanner.Progress, elapsed time.Duration) (time.Duration, bool) {
+	if progress.Total <= 0 || progress.Files <= 0 || progress.Files > progress.Total {
+		return 0, false
+	}
+	perFile := elapsed / time.Duration(progress.Files)
+	return perFile * time.Duration(progress.Total-progress.Files), true
+}
+
+func truncatePath(path string, width int) string {
+	runes := []rune(path)
+	if width <= 1 || len(runes) <= width {
+		return path
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
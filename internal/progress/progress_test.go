@@ -0,0 +1,74 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nouuu/gopeek/internal/scanner"
+)
+
+func TestPrinter_Update(t *testing.T) {
+	var buf strings.Builder
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	printer := New(&buf)
+	printer.now = func() time.Time { return now }
+
+	printer.Update(scanner.Progress{Path: "a.go", Files: 1, Bytes: 2048})
+	if !strings.Contains(buf.String(), "Scanning 1 files · 2.0 KB · a.go") {
+		t.Errorf("Unexpected first line: %q", buf.String())
+	}
+
+	buf.Reset()
+	now = now.Add(refreshInterval / 2)
+	printer.Update(scanner.Progress{Path: "b.go", Files: 2})
+	if buf.Len() != 0 {
+		t.Errorf("Expected updates to be throttled, got: %q", buf.String())
+	}
+
+	buf.Reset()
+	now = now.Add(refreshInterval * 20)
+	printer.Update(scanner.Progress{Path: "c.go", Files: 3, Total: 6})
+	if !strings.Contains(buf.String(), "3/6 files") || !strings.Contains(buf.String(), "ETA 2s") {
+		t.Errorf("Expected total and ETA, got: %q", buf.String())
+	}
+
+	buf.Reset()
+	printer.Done()
+	printer.Done()
+	if buf.String() != "\r\033[K" {
+		t.Errorf("Expected the line to be cleared once, got: %q", buf.String())
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress scanner.Progress
+		elapsed  time.Duration
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "Unknown total", progress: scanner.Progress{Files: 10}, elapsed: time.Second, ok: false},
+		{name: "Halfway", progress: scanner.Progress{Files: 10, Total: 20}, elapsed: time.Second, expected: time.Second, ok: true},
+		{name: "More files than counted", progress: scanner.Progress{Files: 30, Total: 20}, elapsed: time.Second, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eta, ok := estimate(tt.progress, tt.elapsed)
+			if ok != tt.ok || eta != tt.expected {
+				t.Errorf("estimate() = %v, %v, want %v, %v", eta, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTruncatePath(t *testing.T) {
+	if result := truncatePath("internal/scanner/types.go", 10); result != "…/types.go" {
+		t.Errorf("truncatePath() = %q", result)
+	}
+	if result := truncatePath("main.go", 10); result != "main.go" {
+		t.Errorf("truncatePath() = %q", result)
+	}
+}
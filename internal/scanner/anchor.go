@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// slugger generates heading anchors the way GitHub does, so that links to
+// the headings of a document work once it is rendered there. Each anchor is
+// unique within the document: repeated headings get a numbered suffix.
+type slugger struct {
+	occurrences map[string]int
+}
+
+func newSlugger() *slugger {
+	return &slugger{occurrences: make(map[string]int)}
+}
+
+// slug returns the anchor of the next heading with the given text. Headings
+// must be passed in document order for the suffixes to match.
+func (s *slugger) slug(heading string) string {
+	base := slugify(heading)
+	result := base
+	for {
+		if _, taken := s.occurrences[result]; !taken {
+			break
+		}
+		s.occurrences[base]++
+		result = base + "-" + strconv.Itoa(s.occurrences[base])
+	}
+	s.occurrences[result] = 0
+	return result
+}
+
+// slugify lowercases text, drops punctuation, symbols and emoji, and
+// replaces spaces with hyphens. Letters of every script are kept.
+func slugify(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
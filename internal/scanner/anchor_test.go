@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		heading  string
+		expected string
+	}{
+		{heading: "Project Structure", expected: "project-structure"},
+		{heading: "📄 main.go", expected: "-maingo"},
+		{heading: "a.b/c", expected: "abc"},
+		{heading: "a/b-c", expected: "ab-c"},
+		{heading: "docs/日本語.md", expected: "docs日本語md"},
+		{heading: "Ünïcode_File.txt", expected: "ünïcode_filetxt"},
+		{heading: "my file (copy).txt", expected: "my-file-copytxt"},
+	}
+
+	for _, tt := range tests {
+		if result := slugify(tt.heading); result != tt.expected {
+			t.Errorf("slugify(%q) = %q, want %q", tt.heading, result, tt.expected)
+		}
+	}
+}
+
+func TestSlugger(t *testing.T) {
+	slugs := newSlugger()
+	var result []string
+	for _, heading := range []string{"a.b/c", "ab/c", "abc", "abc-1", "Files Content", "abc"} {
+		result = append(result, slugs.slug(heading))
+	}
+
+	expected := "abc,abc-1,abc-2,abc-1-1,files-content,abc-3"
+	if strings.Join(result, ",") != expected {
+		t.Errorf("slugs = %v, want %s", result, expected)
+	}
+}
+
+func TestRender_UniqueAnchors(t *testing.T) {
+	snapshot := func(root string) *Snapshot {
+		return &Snapshot{
+			Root: root,
+			Entries: []Entry{
+				{Path: "a.b", Name: "a.b", IsDir: true},
+				{Path: "a.b/c", Name: "c", Depth: 1},
+				{Path: "ab", Name: "ab", IsDir: true},
+				{Path: "ab/c", Name: "c", Depth: 1},
+			},
+			Files: []File{
+				{Path: "a.b/c", Content: "first"},
+				{Path: "ab/c", Content: "second"},
+			},
+		}
+	}
+
+	var buf strings.Builder
+	if err := (Markdown{NoEmoji: true}).Render(&buf, snapshot("api"), snapshot("web")); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	for _, expect := range []string{
+		"  - [c](#abc)\n", "  - [c](#abc-1)\n", "<a id=\"abc\"></a>\n## a.b/c\n", "<a id=\"abc-1\"></a>\n## ab/c\n",
+		"  - [c](#abc-2)\n", "  - [c](#abc-3)\n", "<a id=\"abc-3\"></a>\n## ab/c\n",
+	} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+}
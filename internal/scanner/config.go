@@ -1,19 +1,84 @@
 package scanner
 
+import (
+	"fmt"
+
+	"github.com/nouuu/gopeek/internal/lang"
+)
+
 var DefaultIgnorePatterns = []string{
 	".git",
 	"go.sum",
 	"node_modules",
 }
 
+// SymlinkPolicy controls how symbolic links found during the walk are handled.
+type SymlinkPolicy string
+
+const (
+	SymlinkSkip   SymlinkPolicy = "skip"   // leave links out of the output
+	SymlinkList   SymlinkPolicy = "list"   // show links and their target in the structure only
+	SymlinkFollow SymlinkPolicy = "follow" // resolve links and scan their target
+)
+
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, error) {
+	switch policy := SymlinkPolicy(value); policy {
+	case SymlinkSkip, SymlinkList, SymlinkFollow:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid symlink policy %q (expected skip, list or follow)", value)
+}
+
 type Config struct {
 	Output         string
 	IgnorePatterns []string
+	Symlinks       SymlinkPolicy
+	Sort           SortOrder
+
+	// MaxDepth limits the depth of the walk, 1 being the entries of the root.
+	// Directories at the limit are collapsed. 0 means no limit.
+	MaxDepth int
+
+	// CollapseThreshold collapses directories holding more entries than this
+	// into a summary, unless they match ExpandPatterns. 0 disables it.
+	CollapseThreshold int
+	ExpandPatterns    []string
+
+	// Slices restrict the content of the files they match to some lines.
+	Slices []Slice
+
+	// Outline replaces the content of source files with a summary of their
+	// declarations. Summarizers add or replace the summarizer of languages,
+	// keyed by language ID or file extension.
+	Outline     bool
+	Summarizers map[string]lang.Summarizer
+
+	// Minify strips comments, trailing whitespace and repeated blank lines
+	// from the content of the files of known languages.
+	Minify bool
+
+	// Overview collects a summary of the manifests at the root of the scan.
+	Overview bool
+
+	// Dependencies collects the graph of the Go packages of the scanned
+	// modules from their go.mod files and imports.
+	Dependencies bool
+
+	// SkipContent lists files without reading them, only their size is known.
+	SkipContent bool
+
+	// OnProgress, when set, is called from the scanning goroutine after each
+	// file is processed.
+	OnProgress func(Progress)
 }
 
 func DefaultConfig() Config {
 	return Config{
 		Output:         "project_knowledge.md",
 		IgnorePatterns: DefaultIgnorePatterns,
+		Symlinks:       SymlinkList,
+		Sort:           SortName,
+
+		CollapseThreshold: 1000,
 	}
 }
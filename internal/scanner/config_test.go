@@ -0,0 +1,14 @@
+package scanner
+
+import "testing"
+
+func TestParseSymlinkPolicy(t *testing.T) {
+	for _, value := range []string{"skip", "list", "follow"} {
+		if policy, err := ParseSymlinkPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("ParseSymlinkPolicy(%q) = %q, %v", value, policy, err)
+		}
+	}
+	if _, err := ParseSymlinkPolicy("maybe"); err == nil {
+		t.Error("Expected error for invalid policy")
+	}
+}
@@ -0,0 +1,221 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nouuu/gopeek/internal/manifest"
+)
+
+// Package is a Go package of the scanned modules.
+type Package struct {
+	Path    string   // import path
+	Dir     string   // slash-separated, relative to the root
+	Imports []string // import paths of the scanned packages it imports, sorted
+}
+
+// Label returns the name of the package in the dependency graph, its
+// directory, or its import path for the root of a module.
+func (p Package) Label() string {
+	if p.Dir == "." {
+		return p.Path
+	}
+	return p.Dir
+}
+
+// collectPackages builds the dependency graph of the Go packages among the
+// scanned entries, for the modules whose go.mod was scanned. Only the
+// imports between these packages are kept, test files are left out.
+func (s *Scanner) collectPackages(ctx context.Context) error {
+	modules := make(map[string]string) // directory → module path
+	for _, entry := range s.output.snapshot.Entries {
+		if entry.Name != "go.mod" || !isReadable(entry) {
+			continue
+		}
+		module, err := parse(s, "module", entry.Path, readModulePath)
+		if err != nil {
+			s.log.Warn("error reading module path", "file", entry.Path, "error", err)
+			continue
+		}
+		modules[path.Dir(entry.Path)] = module
+	}
+	if len(modules) == 0 {
+		return nil
+	}
+
+	packages := make(map[string]*Package) // directory → package
+	imports := make(map[string]map[string]bool)
+	for _, entry := range s.output.snapshot.Entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !isReadable(entry) || path.Ext(entry.Name) != ".go" || strings.HasSuffix(entry.Name, "_test.go") {
+			continue
+		}
+		dir := path.Dir(entry.Path)
+		importPath, ok := packageImportPath(dir, modules)
+		if !ok {
+			continue
+		}
+		paths, err := parse(s, "imports", entry.Path, readImports)
+		if err != nil {
+			s.log.Warn("error reading imports", "file", entry.Path, "error", err)
+			continue
+		}
+		if packages[dir] == nil {
+			packages[dir] = &Package{Path: importPath, Dir: dir}
+			imports[dir] = make(map[string]bool)
+		}
+		for _, p := range paths {
+			imports[dir][p] = true
+		}
+	}
+
+	known := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		known[pkg.Path] = true
+	}
+	result := make([]Package, 0, len(packages))
+	for dir, pkg := range packages {
+		for p := range imports[dir] {
+			if known[p] && p != pkg.Path {
+				pkg.Imports = append(pkg.Imports, p)
+			}
+		}
+		sort.Strings(pkg.Imports)
+		result = append(result, *pkg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	s.output.snapshot.Packages = result
+	return nil
+}
+
+// isReadable reports whether the content of entry can be read through the
+// file system.
+func isReadable(entry Entry) bool {
+	return !entry.IsDir && !entry.Collapsed && !entry.Broken && !entry.Loop
+}
+
+// packageImportPath returns the import path of the package in dir, relative
+// to the module of its nearest parent. Directories the go command ignores do
+// not hold packages.
+func packageImportPath(dir string, modules map[string]string) (string, bool) {
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "testdata" || elem == "vendor" || (elem != "." && (strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_"))) {
+			return "", false
+		}
+	}
+	for moduleDir := dir; ; moduleDir = path.Dir(moduleDir) {
+		if module, ok := modules[moduleDir]; ok {
+			if moduleDir == dir {
+				return module, true
+			}
+			return path.Join(module, strings.TrimPrefix(dir, moduleDir+"/")), true
+		}
+		if moduleDir == "." {
+			return "", false
+		}
+	}
+}
+
+type parsedKey struct {
+	kind string
+	name string
+}
+
+type parsedFile struct {
+	value any
+	err   error
+}
+
+// parse returns what read returns for the file name, calling it only when
+// the file was not read as kind since it last changed.
+func parse[T any](s *Scanner, kind, name string, read func(fs.FS, string) (T, error)) (T, error) {
+	key := parsedKey{kind: kind, name: name}
+	if parsed, ok := s.parsed[key]; ok {
+		return parsed.value.(T), parsed.err
+	}
+	value, err := read(s.fsys, name)
+	if s.parsed == nil {
+		s.parsed = make(map[parsedKey]parsedFile)
+	}
+	s.parsed[key] = parsedFile{value: value, err: err}
+	return value, err
+}
+
+// readModulePath returns the path declared by the module directive of the
+// go.mod file name.
+func readModulePath(fsys fs.FS, name string) (string, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	module, err := manifest.Parse(name, string(content))
+	if err != nil {
+		return "", err
+	}
+	return module.Name, nil
+}
+
+// readImports returns the import paths of the Go file name, parsing its
+// import declarations only.
+func readImports(fsys fs.FS, name string) ([]string, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), name, content, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(file.Imports))
+	for _, spec := range file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// renderDependencies renders the dependency graph of packages as a Mermaid
+// diagram followed by its adjacency list.
+func renderDependencies(packages []Package) string {
+	ids := make(map[string]string, len(packages))
+	labels := make(map[string]string, len(packages))
+	for i, pkg := range packages {
+		ids[pkg.Path] = fmt.Sprintf("p%d", i)
+		labels[pkg.Path] = pkg.Label()
+	}
+
+	var b strings.Builder
+	b.WriteString("```mermaid\ngraph TD\n")
+	for _, pkg := range packages {
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", ids[pkg.Path], strings.ReplaceAll(pkg.Label(), `"`, "#quot;"))
+	}
+	for _, pkg := range packages {
+		for _, imported := range pkg.Imports {
+			fmt.Fprintf(&b, "    %s --> %s\n", ids[pkg.Path], ids[imported])
+		}
+	}
+	b.WriteString("```\n\n")
+
+	for _, pkg := range packages {
+		fmt.Fprintf(&b, "- `%s`", pkg.Label())
+		for i, imported := range pkg.Imports {
+			separator := ", "
+			if i == 0 {
+				separator = " → "
+			}
+			fmt.Fprintf(&b, "%s`%s`", separator, labels[imported])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
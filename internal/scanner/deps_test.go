@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestScanner_Dependencies(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":                         &fstest.MapFile{Data: []byte("// Example module.\nmodule example.com/app // app\n\ngo 1.22\n")},
+		"main.go":                        &fstest.MapFile{Data: []byte("package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/store\"\n\tlog \"example.com/app/internal/log\"\n)\n")},
+		"main_test.go":                   &fstest.MapFile{Data: []byte("package main\n\nimport \"example.com/app/internal/testutil\"\n")},
+		"internal/store/store.go":        &fstest.MapFile{Data: []byte("package store\n\nimport \"example.com/app/internal/log\"\n")},
+		"internal/store/cache.go":        &fstest.MapFile{Data: []byte("package store\n\nimport (\n\t\"example.com/app/internal/log\"\n\t\"example.com/app/internal/store/sub\"\n)\n")},
+		"internal/store/sub/sub.go":      &fstest.MapFile{Data: []byte("package sub\n")},
+		"internal/log/log.go":            &fstest.MapFile{Data: []byte("package log\n\nimport \"os\"\n")},
+		"internal/log/broken.go":         &fstest.MapFile{Data: []byte("package log\n\nimport (\n")},
+		"internal/testutil/util_test.go": &fstest.MapFile{Data: []byte("package testutil\n")},
+		"testdata/fixture.go":            &fstest.MapFile{Data: []byte("package fixture\n\nimport \"example.com/app/internal/log\"\n")},
+		"tools/go.mod":                   &fstest.MapFile{Data: []byte("module \"example.com/tools\"\n")},
+		"tools/gen/gen.go":               &fstest.MapFile{Data: []byte("package gen\n\nimport \"example.com/app/internal/log\"\n")},
+	}
+	config := DefaultConfig()
+	config.Dependencies = true
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Package{
+		{Path: "example.com/app", Dir: ".", Imports: []string{"example.com/app/internal/log", "example.com/app/internal/store"}},
+		{Path: "example.com/app/internal/log", Dir: "internal/log"},
+		{Path: "example.com/app/internal/store", Dir: "internal/store", Imports: []string{"example.com/app/internal/log", "example.com/app/internal/store/sub"}},
+		{Path: "example.com/app/internal/store/sub", Dir: "internal/store/sub"},
+		{Path: "example.com/tools/gen", Dir: "tools/gen", Imports: []string{"example.com/app/internal/log"}},
+	}
+	if packages := scanner.Snapshot().Packages; !reflect.DeepEqual(packages, expected) {
+		t.Errorf("Packages = %+v, want %+v", packages, expected)
+	}
+
+	var buf strings.Builder
+	if err := RenderMarkdown(&buf, scanner.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	for _, expect := range []string{
+		"# Package Dependencies\n\n```mermaid\ngraph TD\n    p0[\"example.com/app\"]\n    p1[\"internal/log\"]\n",
+		"    p0 --> p1\n    p0 --> p2\n    p2 --> p1\n    p2 --> p3\n    p4 --> p1\n```\n",
+		"- `example.com/app` → `internal/log`, `internal/store`\n- `internal/log`\n",
+		"- `tools/gen` → `internal/log`\n\n# Files Content",
+	} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+	if strings.Index(output, "# Project Structure") > strings.Index(output, "# Package Dependencies") {
+		t.Error("Expected the dependencies to follow the structure")
+	}
+}
+
+func TestScanner_DependenciesWithoutModule(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go": &fstest.MapFile{Data: []byte("package main\n")},
+	}
+	config := DefaultConfig()
+	config.Dependencies = true
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if packages := scanner.Snapshot().Packages; packages != nil {
+		t.Errorf("Expected no packages without go.mod, got %+v", packages)
+	}
+
+	var buf strings.Builder
+	if err := RenderMarkdown(&buf, scanner.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Package Dependencies") {
+		t.Errorf("Expected no dependencies section, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// ErrorKind categorizes the paths that could not be fully included.
+type ErrorKind string
+
+const (
+	ErrorPermission ErrorKind = "permission" // access to the path was denied
+	ErrorTooLarge   ErrorKind = "too-large"  // content exceeds the size limit
+	ErrorBinary     ErrorKind = "binary"     // content was replaced by a placeholder
+	ErrorRead       ErrorKind = "read"       // any other error reading the path
+	ErrorSlice      ErrorKind = "slice"      // a slice of the file could not be resolved
+)
+
+var ErrorKinds = []ErrorKind{ErrorPermission, ErrorTooLarge, ErrorBinary, ErrorRead, ErrorSlice}
+
+func ParseErrorKind(value string) (ErrorKind, error) {
+	for _, kind := range ErrorKinds {
+		if string(kind) == value {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("invalid error kind %q (expected permission, too-large, binary, read or slice)", value)
+}
+
+// PathError records a path that was skipped or only partially included
+// during a scan. Scans continue past these errors and collect them in the
+// snapshot.
+type PathError struct {
+	Kind ErrorKind
+	Path string
+	Err  error
+}
+
+var (
+	errTooLarge = errors.New("file too large")
+	errBinary   = errors.New("binary file")
+	errSlice    = errors.New("unresolved slice")
+)
+
+func newPathError(path string, err error) *PathError {
+	kind := ErrorRead
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		kind = ErrorPermission
+	case errors.Is(err, errTooLarge):
+		kind = ErrorTooLarge
+	case errors.Is(err, errBinary):
+		kind = ErrorBinary
+	case errors.Is(err, errSlice):
+		kind = ErrorSlice
+	}
+	return &PathError{Kind: kind, Path: path, Err: err}
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// CheckErrors returns an error joining the errors of snapshots whose kind is
+// one of kinds, or nil when there are none.
+func CheckErrors(kinds []ErrorKind, snapshots ...*Snapshot) error {
+	failOn := make(map[ErrorKind]bool, len(kinds))
+	for _, kind := range kinds {
+		failOn[kind] = true
+	}
+
+	var failures []error
+	for _, snapshot := range snapshots {
+		for _, err := range snapshot.Errors {
+			if failOn[err.Kind] {
+				failures = append(failures, err)
+			}
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		names = append(names, string(kind))
+	}
+	return fmt.Errorf("%d path(s) failed the policy (fail on %s):\n%w",
+		len(failures), strings.Join(names, ", "), errors.Join(failures...))
+}
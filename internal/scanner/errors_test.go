@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestNewPathError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorKind
+	}{
+		{name: "Permission denied", err: &fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}, expected: ErrorPermission},
+		{name: "Too large", err: fmt.Errorf("%w (max 10MB): x", errTooLarge), expected: ErrorTooLarge},
+		{name: "Binary", err: errBinary, expected: ErrorBinary},
+		{name: "Other error", err: fs.ErrNotExist, expected: ErrorRead},
+		{name: "Unresolved slice", err: fmt.Errorf("%w: symbol Nope not found in x", errSlice), expected: ErrorSlice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathErr := newPathError("project/x", tt.err)
+			if pathErr.Kind != tt.expected {
+				t.Errorf("Kind = %q, want %q", pathErr.Kind, tt.expected)
+			}
+			if !errors.Is(pathErr, tt.err) {
+				t.Error("Expected PathError to wrap the original error")
+			}
+			if !strings.HasPrefix(pathErr.Error(), string(tt.expected)+": project/x: ") {
+				t.Errorf("Unexpected message %q", pathErr.Error())
+			}
+		})
+	}
+}
+
+func TestParseErrorKind(t *testing.T) {
+	for _, kind := range ErrorKinds {
+		if parsed, err := ParseErrorKind(string(kind)); err != nil || parsed != kind {
+			t.Errorf("ParseErrorKind(%q) = %q, %v", kind, parsed, err)
+		}
+	}
+	if _, err := ParseErrorKind("everything"); err == nil {
+		t.Error("Expected error for invalid kind")
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":  &fstest.MapFile{Data: []byte("package main")},
+		"logo.png": &fstest.MapFile{Data: []byte{0xFF, 0xD8, 0xFF}},
+		"huge.txt": &fstest.MapFile{Data: make([]byte, maxFileSize+1)},
+	}
+
+	scanner := NewFS("project", fsys, Config{}, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("Expected scan to continue past errors, got: %v", err)
+	}
+	snapshot := scanner.Snapshot()
+
+	if len(snapshot.Errors) != 2 {
+		t.Fatalf("Expected 2 recorded errors, got: %v", snapshot.Errors)
+	}
+
+	tests := []struct {
+		name        string
+		kinds       []ErrorKind
+		expectError bool
+	}{
+		{name: "No policy", kinds: nil, expectError: false},
+		{name: "Unmatched kinds", kinds: []ErrorKind{ErrorPermission, ErrorRead}, expectError: false},
+		{name: "Fail on binary", kinds: []ErrorKind{ErrorBinary}, expectError: true},
+		{name: "Fail on too large", kinds: []ErrorKind{ErrorTooLarge, ErrorRead}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckErrors(tt.kinds, snapshot)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+			var pathErr *PathError
+			if err != nil && !errors.As(err, &pathErr) {
+				t.Errorf("Expected error to wrap a *PathError, got: %v", err)
+			}
+		})
+	}
+}
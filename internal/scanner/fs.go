@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/nouuu/gopeek/internal/archive"
+)
+
+// dirFS is os.DirFS with the ability to read and resolve symbolic links.
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+func newDirFS(dir string) dirFS {
+	return dirFS{FS: os.DirFS(dir), dir: dir}
+}
+
+func (d dirFS) ReadLink(name string) (string, error) {
+	return os.Readlink(d.join(name))
+}
+
+func (d dirFS) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(d.join(name))
+}
+
+func (d dirFS) join(name string) string {
+	return filepath.Join(d.dir, filepath.FromSlash(name))
+}
+
+func isArchiveFile(path string) bool {
+	if !archive.IsArchive(path) {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,399 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nouuu/gopeek/internal/lang"
+)
+
+// StructureStyle controls how the project structure is rendered.
+type StructureStyle string
+
+const (
+	StructureList  StructureStyle = "list"  // nested Markdown list linking to the contents
+	StructureTree  StructureStyle = "tree"  // tree drawn with Unicode box characters
+	StructureASCII StructureStyle = "ascii" // tree drawn with ASCII characters only
+)
+
+func ParseStructureStyle(value string) (StructureStyle, error) {
+	switch style := StructureStyle(value); style {
+	case StructureList, StructureTree, StructureASCII:
+		return style, nil
+	}
+	return "", fmt.Errorf("invalid structure style %q (expected list, tree or ascii)", value)
+}
+
+// Markdown renders snapshots as a single Markdown document. The zero value
+// renders the structure as a list, followed by the contents.
+type Markdown struct {
+	// Summary appends a section with the scan statistics.
+	Summary bool
+
+	// Structure is the style of the structure section, a list when empty.
+	Structure StructureStyle
+
+	// Sizes and Lines annotate the files of the structure with their size
+	// and line count. Line counts need the content, files scanned with
+	// SkipContent have none.
+	Sizes bool
+	Lines bool
+
+	// NoEmoji leaves the emoji out of the structure and headings. Directories
+	// are marked with a trailing slash instead.
+	NoEmoji bool
+
+	// LineNumbers prefixes each line of the file contents with its number.
+	// Numbered contents are not tagged with their language, the numbers
+	// would break its highlighting. Outlined and minified contents are not
+	// numbered, their lines no longer match the file.
+	LineNumbers bool
+
+	// SkipStructure and SkipContents leave out the structure or the contents
+	// section. Entries do not link to contents that are not rendered.
+	SkipStructure bool
+	SkipContents  bool
+}
+
+// RenderMarkdown writes snapshots as a single Markdown document with the
+// default options.
+func RenderMarkdown(w io.Writer, snapshots ...*Snapshot) error {
+	return Markdown{}.Render(w, snapshots...)
+}
+
+// Render writes snapshots to w. When several snapshots are given, each one is
+// rendered as its own section.
+func (m Markdown) Render(w io.Writer, snapshots ...*Snapshot) error {
+	slugs := newSlugger()
+	var document string
+	if len(snapshots) == 1 {
+		document = m.renderSnapshot(snapshots[0], "#", slugs)
+	} else {
+		sections := make([]string, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			title := m.icon("📦") + rootName(snapshot.Root)
+			slugs.slug(title)
+			sections = append(sections, fmt.Sprintf("# %s\n\n%s", title, m.renderSnapshot(snapshot, "##", slugs)))
+		}
+		document = strings.Join(sections, "\n\n")
+	}
+
+	if m.Summary {
+		var stats Stats
+		for _, snapshot := range snapshots {
+			stats.Merge(snapshot.Stats)
+		}
+		document += "\n" + renderSummary(stats)
+	}
+
+	_, err := io.WriteString(w, document)
+	return err
+}
+
+// WriteFile renders snapshots into the file at path, see WriteOutput.
+func (m Markdown) WriteFile(path string, snapshots ...*Snapshot) error {
+	var buf bytes.Buffer
+	if err := m.Render(&buf, snapshots...); err != nil {
+		return err
+	}
+	return WriteOutput(path, buf.Bytes())
+}
+
+// renderSnapshot renders a snapshot using heading as the top-level heading
+// marker, so that it can be nested as a section of a larger document. slugs
+// holds the anchors of the headings already rendered in the document.
+func (m Markdown) renderSnapshot(snapshot *Snapshot, heading string, slugs *slugger) string {
+	files := fileIndex(snapshot)
+
+	// Anchors are taken in the order the headings appear in the document.
+	for _, title := range m.sectionTitles(snapshot) {
+		slugs.slug(title)
+	}
+	anchors := make(map[string]string, len(snapshot.Files))
+	if !m.SkipContents {
+		slugs.slug("Files Content")
+		for _, file := range snapshot.Files {
+			anchors[file.Path] = slugs.slug(m.fileHeading(file))
+		}
+	}
+
+	sections := m.renderSections(snapshot, heading, files, anchors)
+	if !m.SkipContents {
+		contents := make([]string, 0, len(snapshot.Files))
+		for _, file := range snapshot.Files {
+			contents = append(contents, m.renderFile(heading, m.fileHeading(file), anchors[file.Path], file, m.renderBody(file)))
+		}
+		sections = append(sections, fmt.Sprintf("%s Files Content\n%s", heading, strings.Join(contents, "\n")))
+	}
+	return strings.Join(sections, "\n")
+}
+
+func fileIndex(snapshot *Snapshot) map[string]*File {
+	files := make(map[string]*File, len(snapshot.Files))
+	for i := range snapshot.Files {
+		files[snapshot.Files[i].Path] = &snapshot.Files[i]
+	}
+	return files
+}
+
+// sectionTitles returns the headings of the sections preceding the file
+// contents, in the order of renderSections.
+func (m Markdown) sectionTitles(snapshot *Snapshot) []string {
+	var titles []string
+	if len(snapshot.Manifests) > 0 {
+		titles = append(titles, "Project Overview")
+	}
+	if !m.SkipStructure {
+		titles = append(titles, "Project Structure")
+	}
+	if len(snapshot.Packages) > 0 {
+		titles = append(titles, "Package Dependencies")
+	}
+	return titles
+}
+
+// renderSections renders the sections preceding the file contents. Entries
+// of the structure link to their anchor, when they have one.
+func (m Markdown) renderSections(snapshot *Snapshot, heading string, files map[string]*File, anchors map[string]string) []string {
+	var sections []string
+	if len(snapshot.Manifests) > 0 {
+		sections = append(sections, fmt.Sprintf("%s Project Overview\n\n%s", heading, renderOverview(snapshot.Manifests)))
+	}
+	if !m.SkipStructure {
+		structure := m.renderStructure(snapshot, files, anchors)
+		sections = append(sections, fmt.Sprintf("%s Project Structure\n\n%s\n", heading, strings.Join(structure, "\n")))
+	}
+	if len(snapshot.Packages) > 0 {
+		sections = append(sections, fmt.Sprintf("%s Package Dependencies\n\n%s", heading, renderDependencies(snapshot.Packages)))
+	}
+	return sections
+}
+
+// renderStructure returns the lines of the structure section, a list of
+// entries or a tree in a code block.
+func (m Markdown) renderStructure(snapshot *Snapshot, files map[string]*File, anchors map[string]string) []string {
+	if m.treeStructure() {
+		return m.renderTree(snapshot, files)
+	}
+	structure := make([]string, 0, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		structure = append(structure, m.renderEntry(entry, files[entry.Path], anchors[entry.Path]))
+	}
+	return structure
+}
+
+func (m Markdown) treeStructure() bool {
+	return m.Structure == StructureTree || m.Structure == StructureASCII
+}
+
+// renderFile renders body, the content of file, as a code block under a
+// heading titled title.
+func (m Markdown) renderFile(heading, title, anchor string, file File, body string) string {
+	lang := file.Language
+	if file.Binary || m.numbered(file) {
+		lang = ""
+	}
+	return fmt.Sprintf("\n<a id=\"%s\"></a>\n%s %s\n```%s\n%s\n```\n", anchor, heading, title, lang, body)
+}
+
+// renderEntry renders entry as a list item, linking to anchor when its
+// content is part of the document.
+func (m Markdown) renderEntry(entry Entry, file *File, anchor string) string {
+	indent := strings.Repeat("  ", entry.Depth)
+	switch {
+	case entry.Link != "":
+		if anchor != "" {
+			return fmt.Sprintf("%s- %s[%s](#%s) → %s%s", indent, m.icon("🔗"), entry.Name,
+				anchor, linkTarget(entry), m.annotation(entry, file))
+		}
+		return fmt.Sprintf("%s- %s%s → %s%s", indent, m.icon("🔗"), entry.Name, linkTarget(entry), collapsedSummary(entry))
+	case entry.IsDir:
+		return fmt.Sprintf("%s- %s", indent, m.dirName(entry))
+	case anchor == "":
+		return fmt.Sprintf("%s- %s%s%s", indent, m.icon("📄"), entry.Name, m.annotation(entry, file))
+	default:
+		return fmt.Sprintf("%s- %s[%s](#%s)%s", indent, m.icon("📄"), entry.Name, anchor, m.annotation(entry, file))
+	}
+}
+
+func linkTarget(entry Entry) string {
+	switch {
+	case entry.Broken:
+		return entry.Link + " (broken)"
+	case entry.Loop:
+		return entry.Link + " (loop)"
+	}
+	return entry.Link
+}
+
+func (m Markdown) fileHeading(file File) string {
+	if file.Outline {
+		return m.icon("📄") + file.Path + " (outline)"
+	}
+	return m.icon("📄") + file.Path
+}
+
+// renderBody returns the content of file as rendered in its code block, only
+// the selected lines when the file is sliced.
+func (m Markdown) renderBody(file File) string {
+	switch {
+	case file.Binary:
+		return "[binary file]"
+	case len(file.Ranges) == 0 && m.numbered(file):
+		return numberLines(file.Content)
+	case len(file.Ranges) == 0:
+		return file.Content
+	}
+
+	lines := strings.Split(strings.TrimSuffix(file.Content, "\n"), "\n")
+	width := len(strconv.Itoa(file.Ranges[len(file.Ranges)-1].End))
+	var parts []string
+	next := 1
+	for _, r := range file.Ranges {
+		if r.Start > next {
+			parts = append(parts, m.elided(file, next, r.Start-1))
+		}
+		chunk := lines[r.Start-1 : r.End]
+		if m.numbered(file) {
+			chunk = numberLinesFrom(chunk, r.Start, width)
+		}
+		parts = append(parts, chunk...)
+		next = r.End + 1
+	}
+	if next <= len(lines) {
+		parts = append(parts, m.elided(file, next, len(lines)))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// numbered reports whether the lines of file are rendered with their number.
+func (m Markdown) numbered(file File) bool {
+	return m.LineNumbers && !file.Binary && !file.Outline && !file.Minified
+}
+
+// elided returns the marker of the lines start to end left out of file, as
+// a comment when the code block is tagged with the language of file.
+func (m Markdown) elided(file File, start, end int) string {
+	marker := fmt.Sprintf("... lines %d-%d elided ...", start, end)
+	if start == end {
+		marker = fmt.Sprintf("... line %d elided ...", start)
+	}
+	if m.numbered(file) {
+		return marker
+	}
+	return lang.Comment(file.Path, marker)
+}
+
+// numberLines prefixes the lines of content with their number, right
+// aligned so that the code keeps its indentation.
+func numberLines(content string) string {
+	if content == "" {
+		return content
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	numbered := strings.Join(numberLinesFrom(lines, 1, len(strconv.Itoa(len(lines)))), "\n")
+	if strings.HasSuffix(content, "\n") {
+		numbered += "\n"
+	}
+	return numbered
+}
+
+// numberLinesFrom returns lines prefixed with their number, starting at
+// first, padded to width.
+func numberLinesFrom(lines []string, first, width int) []string {
+	numbered := make([]string, len(lines))
+	for i, line := range lines {
+		numbered[i] = strings.TrimRight(fmt.Sprintf("%*d | %s", width, first+i, line), " ")
+	}
+	return numbered
+}
+
+// icon returns emoji followed by a space, or nothing when emoji are disabled.
+func (m Markdown) icon(emoji string) string {
+	if m.NoEmoji {
+		return ""
+	}
+	return emoji + " "
+}
+
+func (m Markdown) dirName(entry Entry) string {
+	if m.NoEmoji {
+		return entry.Name + "/" + collapsedSummary(entry)
+	}
+	return "📁 " + entry.Name + collapsedSummary(entry)
+}
+
+// collapsedSummary describes the content of a collapsed directory.
+func collapsedSummary(entry Entry) string {
+	if !entry.Collapsed {
+		return ""
+	}
+	unit := "files"
+	if entry.Files == 1 {
+		unit = "file"
+	}
+	return fmt.Sprintf(" (%s %s, %s)", formatCount(entry.Files), unit, FormatBytes(entry.Bytes))
+}
+
+// formatCount formats n with thousands separators.
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+// annotation returns the size and line count of a file when enabled.
+func (m Markdown) annotation(entry Entry, file *File) string {
+	var details []string
+	if m.Sizes {
+		details = append(details, FormatBytes(entry.Size))
+	}
+	if m.Lines && file != nil && !file.Binary {
+		lines := file.Lines
+		unit := "lines"
+		if lines == 1 {
+			unit = "line"
+		}
+		details = append(details, fmt.Sprintf("%d %s", lines, unit))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+func rootName(root string) string {
+	return filepath.ToSlash(filepath.Clean(root))
+}
+
+// renderSummary renders the statistics of a scan. The elapsed time is left
+// out so that identical inputs produce identical documents.
+func renderSummary(stats Stats) string {
+	var b strings.Builder
+	b.WriteString("\n# Scan Summary\n\n")
+	b.WriteString("| Metric | Count |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Files included | %d |\n", stats.Files)
+	fmt.Fprintf(&b, "| Skipped (ignored) | %d |\n", stats.Ignored)
+	fmt.Fprintf(&b, "| Skipped (binary) | %d |\n", stats.Binary)
+	fmt.Fprintf(&b, "| Skipped (too large) | %d |\n", stats.TooLarge)
+	fmt.Fprintf(&b, "| Errors | %d |\n", stats.Errors)
+
+	if len(stats.Languages) > 0 {
+		b.WriteString("\n| Language | Files | Lines | Bytes |\n|---|---|---|---|\n")
+		for _, name := range stats.LanguageNames() {
+			lang := stats.Languages[name]
+			fmt.Fprintf(&b, "| %s | %d | %d | %d |\n", name, lang.Files, lang.Lines, lang.Bytes)
+		}
+	}
+	return b.String()
+}
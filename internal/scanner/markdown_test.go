@@ -0,0 +1,330 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	api := fstest.MapFS{
+		"main.go":      &fstest.MapFile{Data: []byte("package main")},
+		".gitignore":   &fstest.MapFile{Data: []byte("*.gen.go")},
+		"types.gen.go": &fstest.MapFile{Data: []byte("package main")},
+	}
+	web := fstest.MapFS{
+		"index.js":      &fstest.MapFile{Data: []byte("console.log('web')")},
+		"types.gen.go":  &fstest.MapFile{Data: []byte("kept: not ignored in this root")},
+		"sub/style.css": &fstest.MapFile{Data: []byte("body {}")},
+	}
+
+	snapshots := make([]*Snapshot, 0, 2)
+	for name, fsys := range map[string]fstest.MapFS{"api": api, "web": web} {
+		s := NewFS(name, fsys, Config{}, logger.Default())
+		if err := s.Scan(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, s.Snapshot())
+	}
+	if snapshots[0].Root != "api" {
+		snapshots[0], snapshots[1] = snapshots[1], snapshots[0]
+	}
+
+	tests := []struct {
+		name              string
+		snapshots         []*Snapshot
+		expectInOutput    []string
+		expectNotInOutput []string
+	}{
+		{
+			name:      "Single root",
+			snapshots: snapshots[:1],
+			expectInOutput: []string{
+				"# Project Structure\n\n- 📄 [.gitignore](#-gitignore)\n- 📄 [main.go](#-maingo)\n\n# Files Content\n",
+				"\n<a id=\"-maingo\"></a>\n# 📄 main.go\n```go\npackage main\n```\n",
+			},
+			expectNotInOutput: []string{"# 📦", "types.gen.go"},
+		},
+		{
+			name:      "Multiple roots",
+			snapshots: snapshots,
+			expectInOutput: []string{
+				"# 📦 api\n\n## Project Structure\n",
+				"# 📦 web\n\n## Project Structure\n",
+				"## 📄 sub/style.css",
+				"kept: not ignored in this root",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := RenderMarkdown(&buf, tt.snapshots...); err != nil {
+				t.Fatal(err)
+			}
+			output := buf.String()
+
+			for _, expect := range tt.expectInOutput {
+				if !strings.Contains(output, expect) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+				}
+			}
+			for _, notExpect := range tt.expectNotInOutput {
+				if strings.Contains(output, notExpect) {
+					t.Errorf("Expected output to not contain %q", notExpect)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdown_Summary(t *testing.T) {
+	snapshot := &Snapshot{Root: "project", Stats: Stats{Ignored: 2, Elapsed: 42}}
+	snapshot.Stats.add("go", 13, 1)
+
+	var buf strings.Builder
+	if err := (Markdown{Summary: true}).Render(&buf, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	for _, expect := range []string{"# Scan Summary", "| Files included | 1 |", "| Skipped (ignored) | 2 |", "| go | 1 | 1 | 13 |"} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+
+	buf.Reset()
+	if err := RenderMarkdown(&buf, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Scan Summary") {
+		t.Error("Expected no summary section by default")
+	}
+}
+
+func TestMarkdown_Structure(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":           &fstest.MapFile{Data: []byte("package main\n\nfunc main() {}\n")},
+		"README.md":         &fstest.MapFile{Data: []byte("# Demo")},
+		"internal/a/a.go":   &fstest.MapFile{Data: []byte("package a\n")},
+		"internal/b/b.go":   &fstest.MapFile{Data: []byte("package b\n")},
+		"internal/notes.md": &fstest.MapFile{Data: []byte("notes\n")},
+	}
+	s := NewFS("demo", fsys, Config{}, logger.Default())
+	if err := s.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		markdown          Markdown
+		expectInOutput    []string
+		expectNotInOutput []string
+	}{
+		{
+			name:     "Unicode tree",
+			markdown: Markdown{Structure: StructureTree},
+			expectInOutput: []string{
+				"```\ndemo\n" +
+					"├── 📄 README.md\n" +
+					"├── 📁 internal\n" +
+					"│   ├── 📁 a\n" +
+					"│   │   └── 📄 a.go\n" +
+					"│   ├── 📁 b\n" +
+					"│   │   └── 📄 b.go\n" +
+					"│   └── 📄 notes.md\n" +
+					"└── 📄 main.go\n" +
+					"```\n",
+			},
+		},
+		{
+			name:     "ASCII tree without emoji",
+			markdown: Markdown{Structure: StructureASCII, NoEmoji: true},
+			expectInOutput: []string{
+				"```\ndemo\n" +
+					"|-- README.md\n" +
+					"|-- internal/\n" +
+					"|   |-- a/\n" +
+					"|   |   `-- a.go\n" +
+					"|   |-- b/\n" +
+					"|   |   `-- b.go\n" +
+					"|   `-- notes.md\n" +
+					"`-- main.go\n" +
+					"```\n",
+				"\n# main.go\n",
+			},
+			expectNotInOutput: []string{"📁", "📄"},
+		},
+		{
+			name:     "List with sizes and line counts",
+			markdown: Markdown{Sizes: true, Lines: true},
+			expectInOutput: []string{
+				"- 📄 [main.go](#-maingo) (29 B, 3 lines)",
+				"- 📄 [README.md](#-readmemd) (6 B, 1 line)",
+			},
+		},
+		{
+			name:     "List without emoji",
+			markdown: Markdown{NoEmoji: true},
+			expectInOutput: []string{
+				"- internal/\n  - a/\n    - [a.go](#internalaago)\n",
+			},
+			expectNotInOutput: []string{"📁", "📄"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := tt.markdown.Render(&buf, s.Snapshot()); err != nil {
+				t.Fatal(err)
+			}
+			output := buf.String()
+
+			for _, expect := range tt.expectInOutput {
+				if !strings.Contains(output, expect) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+				}
+			}
+			for _, notExpect := range tt.expectNotInOutput {
+				if strings.Contains(output, notExpect) {
+					t.Errorf("Expected output to not contain %q", notExpect)
+				}
+			}
+		})
+	}
+}
+
+func TestParseStructureStyle(t *testing.T) {
+	for _, value := range []string{"list", "tree", "ascii"} {
+		if style, err := ParseStructureStyle(value); err != nil || string(style) != value {
+			t.Errorf("ParseStructureStyle(%q) = %q, %v", value, style, err)
+		}
+	}
+	if _, err := ParseStructureStyle("html"); err == nil {
+		t.Error("Expected error for invalid style")
+	}
+}
+
+func TestMarkdown_SkipSections(t *testing.T) {
+	snapshot := &Snapshot{
+		Root: "project",
+		Entries: []Entry{
+			{Path: "main.go", Name: "main.go", Size: 12},
+		},
+		Files: []File{
+			{Path: "main.go", Language: "go", Content: "package main"},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		markdown          Markdown
+		expectInOutput    []string
+		expectNotInOutput []string
+	}{
+		{
+			name:              "Structure only",
+			markdown:          Markdown{SkipContents: true},
+			expectInOutput:    []string{"# Project Structure\n\n- 📄 main.go\n"},
+			expectNotInOutput: []string{"Files Content", "package main", "](#"},
+		},
+		{
+			name:              "Contents only",
+			markdown:          Markdown{SkipStructure: true},
+			expectInOutput:    []string{"# Files Content\n\n<a id=\"-maingo\"></a>\n# 📄 main.go\n"},
+			expectNotInOutput: []string{"Project Structure"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := tt.markdown.Render(&buf, snapshot); err != nil {
+				t.Fatal(err)
+			}
+			output := buf.String()
+
+			for _, expect := range tt.expectInOutput {
+				if !strings.Contains(output, expect) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+				}
+			}
+			for _, notExpect := range tt.expectNotInOutput {
+				if strings.Contains(output, notExpect) {
+					t.Errorf("Expected output to not contain %q", notExpect)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := map[int]string{0: "0", 12: "12", 999: "999", 1000: "1,000", 2314: "2,314", 1234567: "1,234,567"}
+	for n, expected := range tests {
+		if result := formatCount(n); result != expected {
+			t.Errorf("formatCount(%d) = %q, want %q", n, result, expected)
+		}
+	}
+}
+
+func TestNumberLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "Empty", content: "", expected: ""},
+		{name: "Single line", content: "package main", expected: "1 | package main"},
+		{name: "Trailing newline", content: "a\n\tb\n", expected: "1 | a\n2 | \tb\n"},
+		{
+			name:     "Aligned numbers",
+			content:  strings.Repeat("x\n", 9) + "\ny",
+			expected: " 1 | x\n 2 | x\n 3 | x\n 4 | x\n 5 | x\n 6 | x\n 7 | x\n 8 | x\n 9 | x\n10 |\n11 | y",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := numberLines(tt.content); result != tt.expected {
+				t.Errorf("numberLines() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarkdown_LineNumbers(t *testing.T) {
+	snapshot := &Snapshot{
+		Root:    "project",
+		Entries: []Entry{{Path: "main.go", Name: "main.go"}, {Path: "logo.png", Name: "logo.png"}},
+		Files: []File{
+			{Path: "main.go", Language: "go", Content: "package main\n\nfunc main() {}\n"},
+			{Path: "logo.png", Binary: true},
+			{Path: "min.go", Language: "go", Content: "package min\n", Minified: true},
+			{Path: "api.go", Language: "go", Content: "package api\n", Outline: true},
+		},
+	}
+
+	var buf strings.Builder
+	if err := (Markdown{LineNumbers: true}).Render(&buf, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	// Numbered lines are not valid code, the language is left out.
+	for _, expect := range []string{
+		"```\n1 | package main\n2 |\n3 | func main() {}\n\n```",
+		"```\n[binary file]\n```",
+		"```go\npackage min\n\n```",
+		"```go\npackage api\n\n```",
+	} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/nouuu/gopeek/internal/manifest"
+)
+
+// collectManifests summarizes the known manifests at the root of the scan.
+func (s *Scanner) collectManifests() {
+	for _, entry := range s.output.snapshot.Entries {
+		if path.Dir(entry.Path) != "." || !isReadable(entry) {
+			continue
+		}
+		if _, ok := manifest.Detect(entry.Name); !ok {
+			continue
+		}
+		summary, err := parse(s, "manifest", entry.Path, readManifest)
+		if err != nil {
+			s.log.Warn("error reading manifest", "file", entry.Path, "error", err)
+			continue
+		}
+		s.output.snapshot.Manifests = append(s.output.snapshot.Manifests, summary)
+	}
+	manifest.Sort(s.output.snapshot.Manifests)
+}
+
+// readManifest reads and summarizes the manifest name.
+func readManifest(fsys fs.FS, name string) (manifest.Manifest, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return manifest.Manifest{}, err
+	}
+	return manifest.Parse(name, string(content))
+}
+
+// renderOverview renders the manifests of a project as a list, one item
+// per manifest with its details nested.
+func renderOverview(manifests []manifest.Manifest) string {
+	var b strings.Builder
+	for _, m := range manifests {
+		fmt.Fprintf(&b, "- **%s**", m.Path)
+		if m.Name != "" {
+			fmt.Fprintf(&b, ": `%s`", m.Name)
+		}
+		b.WriteString("\n")
+
+		scripts := "Scripts"
+		if m.Kind == "Makefile" {
+			scripts = "Targets"
+		}
+		for _, detail := range []struct {
+			label  string
+			values []string
+		}{
+			{"Versions", m.Versions},
+			{"Dependencies", m.Dependencies},
+			{scripts, m.Scripts},
+			{"Entrypoints", m.Entrypoints},
+		} {
+			if len(detail.values) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "  - %s (%d): `%s`\n", detail.label, len(detail.values), strings.Join(detail.values, "`, `"))
+		}
+	}
+	return b.String()
+}
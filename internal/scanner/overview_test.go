@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestScanner_Overview(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":           &fstest.MapFile{Data: []byte("module example.com/app\n\ngo 1.22\n\nrequire github.com/spf13/cobra v1.9.1\n")},
+		"Makefile":         &fstest.MapFile{Data: []byte(".PHONY: build\nbuild:\n\tgo build ./...\n")},
+		"package.json":     &fstest.MapFile{Data: []byte("{")},
+		"web/package.json": &fstest.MapFile{Data: []byte(`{"name": "web"}`)},
+		"main.go":          &fstest.MapFile{Data: []byte("package main\n")},
+	}
+	config := DefaultConfig()
+	config.Overview = true
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	manifests := scanner.Snapshot().Manifests
+	if len(manifests) != 2 || manifests[0].Path != "go.mod" || manifests[1].Path != "Makefile" {
+		t.Fatalf("Expected go.mod and Makefile manifests, got %+v", manifests)
+	}
+
+	var buf strings.Builder
+	if err := RenderMarkdown(&buf, scanner.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Project Overview\n\n" +
+		"- **go.mod**: `example.com/app`\n" +
+		"  - Versions (1): `go 1.22`\n" +
+		"  - Dependencies (1): `github.com/spf13/cobra v1.9.1`\n" +
+		"- **Makefile**\n" +
+		"  - Targets (1): `build`\n" +
+		"\n# Project Structure\n"
+	if output := buf.String(); !strings.HasPrefix(output, expected) {
+		t.Errorf("Expected output to start with %q, got:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// Progress describes the state of a running scan.
+type Progress struct {
+	Root  string
+	Path  string // path being processed
+	Files int    // files visited so far
+	Bytes int64  // content bytes read so far
+	Total int    // files expected in total, 0 until the pre-count is done or without one
+}
+
+// progress tracks the state reported to Config.OnProgress. The expected
+// total is computed concurrently with the scan, hence atomic.
+type progress struct {
+	files int
+	total atomic.Int64
+}
+
+// startCount counts the files the scan is expected to visit in the
+// background, so that progress can include an estimate of the remaining
+// work once it is known. The returned function waits for the count to stop.
+// Nothing is counted for file systems that are not known to be safe for
+// concurrent use.
+func (s *Scanner) startCount(ctx context.Context) (wait func()) {
+	s.progress.files = 0
+	s.progress.total.Store(0)
+	if s.config.OnProgress == nil || !s.precount {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		total := 0
+		err := fs.WalkDir(s.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil || name == "." {
+				return nil
+			}
+			if s.shouldIgnore(filepath.Join(s.rootDir, filepath.FromSlash(name))) {
+				return skipIfDir(d)
+			}
+			if d.IsDir() {
+				if _, collapsed, _ := s.listDir(name, strings.Count(name, "/")); collapsed {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			total++
+			return nil
+		})
+		if err == nil {
+			s.progress.total.Store(int64(total))
+		}
+	}()
+	return func() { <-done }
+}
+
+func (s *Scanner) reportProgress(path string) {
+	if s.config.OnProgress == nil {
+		return
+	}
+	s.progress.files++
+	s.config.OnProgress(Progress{
+		Root:  s.rootDir,
+		Path:  path,
+		Files: s.progress.files,
+		Bytes: s.output.snapshot.Stats.Bytes,
+		Total: int(s.progress.total.Load()),
+	})
+}
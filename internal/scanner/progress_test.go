@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestScanner_OnProgress(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":       &fstest.MapFile{Data: []byte("aaaa")},
+		"dir/b.txt":   &fstest.MapFile{Data: []byte("bb")},
+		"dir/c.txt":   &fstest.MapFile{Data: []byte("c")},
+		"ignored/d.x": &fstest.MapFile{Data: []byte("d")},
+	}
+
+	var updates []Progress
+	cfg := Config{
+		IgnorePatterns: []string{"ignored"},
+		OnProgress:     func(p Progress) { updates = append(updates, p) },
+	}
+	scanner := NewFS("project", fsys, cfg, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updates) != 3 {
+		t.Fatalf("Expected one update per file, got %d", len(updates))
+	}
+	last := updates[len(updates)-1]
+	if last.Files != 3 || last.Bytes != 7 || last.Root != "project" {
+		t.Errorf("Unexpected last update: %+v", last)
+	}
+	for _, update := range updates {
+		// File systems given to NewFS are not walked concurrently to count
+		// their files.
+		if update.Total != 0 {
+			t.Errorf("Unexpected total: %+v", update)
+		}
+	}
+}
+
+func TestScanner_OnProgressTotal(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var updates []Progress
+	cfg := Config{OnProgress: func(p Progress) { updates = append(updates, p) }}
+	if err := New(tmpDir, cfg, logger.Default()).Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, update := range updates {
+		// The pre-count runs concurrently: the total is either unknown yet
+		// or exact.
+		if update.Total != 0 && update.Total != 3 {
+			t.Errorf("Unexpected total: %+v", update)
+		}
+	}
+}
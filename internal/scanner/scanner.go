@@ -1,113 +1,511 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/nouuu/gopeek/internal/archive"
 	"github.com/nouuu/gopeek/internal/ignore"
 	"github.com/nouuu/gopeek/internal/logger"
 )
 
 type Scanner struct {
 	rootDir       string
+	fsys          fs.FS
 	config        Config
 	output        Output
 	ignoreMatcher *ignore.Matcher
+	expandMatcher *ignore.Matcher
 	log           *logger.Logger
+
+	// followed holds the resolved directories currently being walked, used to
+	// detect symlink loops when following links.
+	followed []string
+	progress progress
+	// precount is set for the file systems of New, which can be walked by
+	// the progress pre-count while the scan reads them.
+	precount bool
+	// parsed keeps what finish read from each file, so that updates only
+	// read the files that changed again.
+	parsed map[parsedKey]parsedFile
 }
 
+// New creates a scanner for rootDir, which is either a directory or an
+// archive file. Archives are only opened while scanning.
 func New(rootDir string, config Config, log *logger.Logger) *Scanner {
+	var s *Scanner
+	if isArchiveFile(rootDir) {
+		s = newScanner(rootDir, nil, config, log)
+	} else {
+		s = NewFS(rootDir, newDirFS(rootDir), config, log)
+	}
+	s.precount = true
+	return s
+}
+
+// NewFS creates a scanner walking fsys. name identifies the root in the
+// generated output and anchors. fsys is only used from the scanning
+// goroutine, so progress reports no expected total.
+func NewFS(name string, fsys fs.FS, config Config, log *logger.Logger) *Scanner {
+	s := newScanner(name, fsys, config, log)
+	s.loadGitignore()
+	return s
+}
+
+func newScanner(rootDir string, fsys fs.FS, config Config, log *logger.Logger) *Scanner {
 	ignoreList := ignore.NewMatcher()
 
 	for _, pattern := range config.IgnorePatterns {
 		ignoreList.AddPattern(pattern)
 	}
 
-	gitignorePath := filepath.Join(rootDir, ".gitignore")
-	if _, err := os.Stat(gitignorePath); err == nil {
-		log.Debug("loading .gitignore file", "path", gitignorePath)
-		if err = ignoreList.LoadFile(gitignorePath); err != nil {
-			log.Warn("error loading .gitignore file", "error", err)
-		}
+	expandList := ignore.NewMatcher()
+	for _, pattern := range config.ExpandPatterns {
+		expandList.AddPattern(pattern)
 	}
 
 	return &Scanner{
 		rootDir: rootDir,
+		fsys:    fsys,
 		config:  config,
 		output: Output{
-			log: log,
+			snapshot:    Snapshot{Root: rootDir},
+			slices:      config.Slices,
+			outline:     config.Outline,
+			summarizers: config.Summarizers,
+			minify:      config.Minify,
+			log:         log,
 		},
 		ignoreMatcher: ignoreList,
+		expandMatcher: expandList,
 		log:           log,
 	}
 }
 
-func (s *Scanner) Run() error {
-	if err := s.scan(); err != nil {
-		return fmt.Errorf("scanning error: %w", err)
+func (s *Scanner) loadGitignore() {
+	f, err := s.fsys.Open(".gitignore")
+	if err != nil {
+		return
 	}
+	defer f.Close()
 
-	output := s.output.Generate()
+	s.log.Debug("loading .gitignore file", "path", filepath.Join(s.rootDir, ".gitignore"))
+	if err = s.ignoreMatcher.Load(f); err != nil {
+		s.log.Warn("error loading .gitignore file", "error", err)
+	}
+}
+
+func (s *Scanner) Run(ctx context.Context) error {
+	if err := s.Scan(ctx); err != nil {
+		return err
+	}
 	s.log.Info("writing output", "file", s.config.Output)
-	return os.WriteFile(s.config.Output, []byte(output), 0o644)
+	return WriteOutput(s.config.Output, []byte(s.output.Generate()))
+}
+
+// Scan walks the root directory and collects its structure and content
+// without writing anything. The walk stops as soon as ctx is done.
+func (s *Scanner) Scan(ctx context.Context) error {
+	start := time.Now()
+	defer func() { s.output.snapshot.Stats.Elapsed = time.Since(start) }()
+
+	if s.fsys == nil {
+		if err := s.openArchive(ctx); err != nil {
+			return fmt.Errorf("scanning error: %w", err)
+		}
+		defer s.closeArchive()
+	}
+	if err := s.scan(ctx); err != nil {
+		return fmt.Errorf("scanning error: %w", err)
+	}
+	if err := s.finish(ctx); err != nil {
+		return fmt.Errorf("scanning error: %w", err)
+	}
+	return nil
+}
+
+// finish collects what is derived from the whole tree once it is walked,
+// and puts the entries in order.
+func (s *Scanner) finish(ctx context.Context) error {
+	if s.config.Overview {
+		s.output.snapshot.Manifests = nil
+		s.collectManifests()
+	}
+	if s.config.Dependencies {
+		if err := s.collectPackages(ctx); err != nil {
+			return err
+		}
+	}
+	sortSnapshot(&s.output.snapshot, s.config.Sort)
+	return nil
 }
 
 func (s *Scanner) Output() Output {
 	return s.output
 }
 
-func (s *Scanner) scan() error {
-	return filepath.Walk(s.rootDir, s.processPath)
+func (s *Scanner) Snapshot() *Snapshot {
+	return s.output.Snapshot()
+}
+
+// openArchive opens the archive at the root, entries over the maximum file
+// size are not read.
+func (s *Scanner) openArchive(ctx context.Context) error {
+	fsys, err := archive.Open(ctx, s.rootDir, maxFileSize)
+	if err != nil {
+		return fmt.Errorf("error opening archive %s: %w", s.rootDir, err)
+	}
+	s.fsys = fsys
+	s.loadGitignore()
+	return nil
+}
+
+// closeArchive releases the archive opened for the scan.
+func (s *Scanner) closeArchive() {
+	if closer, ok := s.fsys.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			s.log.Warn("error closing archive", "path", s.rootDir, "error", err)
+		}
+	}
+	s.fsys = nil
+}
+
+func (s *Scanner) scan(ctx context.Context) error {
+	s.followed = nil
+	if realRoot, err := s.realPath("."); err == nil {
+		s.followed = append(s.followed, realRoot)
+	}
+
+	countCtx, cancel := context.WithCancel(ctx)
+	wait := s.startCount(countCtx)
+	defer wait()
+	defer cancel()
+
+	return fs.WalkDir(s.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		return s.processPath(ctx, name, d, err)
+	})
 }
 
-func (s *Scanner) processPath(path string, info fs.FileInfo, err error) error {
+func (s *Scanner) processPath(ctx context.Context, name string, d fs.DirEntry, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	path := filepath.Join(s.rootDir, filepath.FromSlash(name))
 	if err != nil {
-		s.log.Debug("error accessing path", "path", path, "error", err)
-		return fmt.Errorf("error accessing path %s: %w", path, err)
+		if name == "." {
+			return fmt.Errorf("error accessing path %s: %w", path, err)
+		}
+		s.recordError(path, err)
+		return nil
+	}
+
+	if name == "." {
+		return nil
 	}
 
 	s.log.Debug("processing path", "path", path)
 
 	if s.shouldIgnore(path) {
 		s.log.Debug("ignoring path", "path", path)
-		return skipIfDir(info)
+		s.output.ignore(name)
+		return skipIfDir(d)
 	}
 
-	relPath, err := filepath.Rel(s.rootDir, path)
+	info, err := d.Info()
 	if err != nil {
-		s.log.Debug("error getting relative path", "path", path, "error", err)
-		return fmt.Errorf("error getting relative path for %s: %w", path, err)
+		s.recordError(path, err)
+		return skipIfDir(d)
 	}
 
-	if relPath == "." {
-		return nil
+	depth := strings.Count(name, "/")
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return s.processSymlink(ctx, name, path, info, depth)
 	}
 
-	depth := strings.Count(relPath, string(os.PathSeparator))
-	s.output.AddStructure(path, info, depth)
-
 	if !info.IsDir() {
-		if err := s.output.AddContent(path, relPath); err != nil {
-			s.log.Warn("error adding content", "path", path, "error", err)
+		s.output.AddStructure(name, info, depth)
+		return s.addFile(ctx, name, path, info)
+	}
+
+	// Directories are walked here rather than by the caller, so that they
+	// are read once for both the collapse threshold and their content.
+	entries, collapsed, err := s.listDir(name, depth)
+	if collapsed {
+		s.log.Debug("collapsing directory", "path", path)
+		entry := newEntry(name, info, depth)
+		if err := s.collapse(ctx, &entry, name); err != nil {
+			return err
 		}
+		s.output.AddEntry(entry)
+		return fs.SkipDir
 	}
 
+	s.output.AddStructure(name, info, depth)
+	if err != nil {
+		s.recordError(path, err)
+	}
+	if err := s.walkEntries(ctx, name, entries); err != nil {
+		return err
+	}
+	return fs.SkipDir
+}
+
+// listDir reads the directory name, unless it is at the depth limit, and
+// reports whether it is collapsed instead of walked.
+func (s *Scanner) listDir(name string, depth int) (entries []fs.DirEntry, collapsed bool, err error) {
+	if s.atMaxDepth(depth) {
+		return nil, true, nil
+	}
+	entries, err = fs.ReadDir(s.fsys, name)
+	return entries, err == nil && s.isCrowded(name, entries), err
+}
+
+// walkEntries processes the entries of the directory name, as fs.WalkDir
+// would.
+func (s *Scanner) walkEntries(ctx context.Context, name string, entries []fs.DirEntry) error {
+	for _, d := range entries {
+		if err := s.processPath(ctx, path.Join(name, d.Name()), d, nil); err != nil && err != fs.SkipDir {
+			return err
+		}
+	}
 	return nil
 }
 
-func skipIfDir(info fs.FileInfo) error {
-	if info.IsDir() {
+// addFile includes the content of the file name, or only its size when
+// content is skipped.
+func (s *Scanner) addFile(ctx context.Context, name, path string, info fs.FileInfo) error {
+	if s.config.SkipContent {
+		s.output.count(name, fileLanguage(name), info.Size(), 0)
+	} else if err := s.output.AddContent(ctx, s.fsys, name); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.recordError(path, err)
+	}
+	s.reportProgress(path)
+	return nil
+}
+
+func (s *Scanner) processSymlink(ctx context.Context, name, path string, info fs.FileInfo, depth int) error {
+	if s.config.Symlinks == SymlinkSkip {
+		s.log.Debug("skipping symlink", "path", path)
+		return nil
+	}
+
+	target, err := archive.ReadLink(s.fsys, name)
+	if err != nil {
+		s.recordError(path, fmt.Errorf("error reading symlink: %w", err))
+		return nil
+	}
+
+	entry := newEntry(name, info, depth)
+	entry.Link = target
+
+	if s.config.Symlinks != SymlinkFollow {
+		s.output.AddEntry(entry)
+		return nil
+	}
+
+	targetInfo, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		s.log.Warn("broken symlink", "path", path, "target", target, "error", err)
+		entry.Broken = true
+		s.output.AddEntry(entry)
+		return nil
+	}
+
+	// File systems that do not resolve links report the link itself.
+	if targetInfo.Mode()&fs.ModeSymlink != 0 {
+		s.output.AddEntry(entry)
+		return nil
+	}
+
+	if !targetInfo.IsDir() {
+		entry.Size = targetInfo.Size()
+		s.output.AddEntry(entry)
+		return s.addFile(ctx, name, path, targetInfo)
+	}
+
+	realPath, err := s.realPath(name)
+	if err != nil || s.isLoop(name, realPath) {
+		s.log.Debug("symlink loop detected", "path", path, "target", target)
+		entry.Loop = true
+		s.output.AddEntry(entry)
+		return nil
+	}
+
+	entries, collapsed, err := s.listDir(name, depth)
+	if collapsed {
+		if err := s.collapse(ctx, &entry, name); err != nil {
+			return err
+		}
+		s.output.AddEntry(entry)
+		return nil
+	}
+
+	s.output.AddEntry(entry)
+	if err != nil {
+		s.recordError(path, err)
+	}
+	s.followed = append(s.followed, realPath)
+	defer func() { s.followed = s.followed[:len(s.followed)-1] }()
+	return s.walkEntries(ctx, name, entries)
+}
+
+// atMaxDepth reports whether directories at depth are at the depth limit,
+// their content is not walked.
+func (s *Scanner) atMaxDepth(depth int) bool {
+	return s.config.MaxDepth > 0 && depth+1 >= s.config.MaxDepth
+}
+
+// isCrowded reports whether the directory name holds more entries than the
+// collapse threshold and was not explicitly expanded. Ignored entries do not
+// count.
+func (s *Scanner) isCrowded(name string, entries []fs.DirEntry) bool {
+	if s.config.CollapseThreshold <= 0 || len(entries) <= s.config.CollapseThreshold || s.expandMatcher.ShouldIgnore(name) {
+		return false
+	}
+	kept := 0
+	for _, d := range entries {
+		if !s.shouldIgnore(filepath.Join(s.rootDir, filepath.FromSlash(name), d.Name())) {
+			kept++
+		}
+	}
+	return kept > s.config.CollapseThreshold
+}
+
+// collapse summarizes the files below the directory name in entry instead of
+// adding them to the output. Only the metadata of the files is read.
+func (s *Scanner) collapse(ctx context.Context, entry *Entry, name string) error {
+	entry.Collapsed = true
+	return fs.WalkDir(s.fsys, name, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil || p == name {
+			return nil
+		}
+		if s.shouldIgnore(filepath.Join(s.rootDir, filepath.FromSlash(p))) {
+			return skipIfDir(d)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		entry.Files++
+		if info, err := d.Info(); err == nil {
+			entry.Bytes += info.Size()
+		}
+		return nil
+	})
+}
+
+// realPath resolves name to its location on disk. Only file systems able to
+// evaluate links support it, loop detection is impossible otherwise.
+func (s *Scanner) realPath(name string) (string, error) {
+	if fsys, ok := s.fsys.(interface {
+		EvalSymlinks(name string) (string, error)
+	}); ok {
+		return fsys.EvalSymlinks(name)
+	}
+	return "", fmt.Errorf("cannot resolve %s: %w", name, errors.ErrUnsupported)
+}
+
+// isLoop reports whether following a directory link would lead back into a
+// directory that is already being walked.
+func (s *Scanner) isLoop(name, realPath string) bool {
+	parents := s.followed
+	if realParent, err := s.realPath(path.Dir(name)); err == nil {
+		parents = append([]string{realParent}, parents...)
+	}
+	for _, parent := range parents {
+		if isWithin(parent, realPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is dir or one of its descendants.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// recordError adds a path that could not be fully included to the snapshot,
+// the scan carries on with the next path.
+func (s *Scanner) recordError(path string, err error) {
+	pathErr := newPathError(path, err)
+	s.output.snapshot.Errors = append(s.output.snapshot.Errors, pathErr)
+
+	stats := &s.output.snapshot.Stats
+	switch pathErr.Kind {
+	case ErrorBinary:
+		stats.Binary++
+		s.log.Debug("binary file", "path", path)
+		return
+	case ErrorTooLarge:
+		stats.TooLarge++
+	default:
+		stats.Errors++
+	}
+	s.log.Warn("error adding path", "path", path, "kind", pathErr.Kind, "error", err)
+}
+
+// isOutput reports whether path is the output file, one of its parts, or a
+// temporary file written in their place, which are never part of a scan.
+func (s *Scanner) isOutput(path string) bool {
+	if s.config.Output == "" {
+		return false
+	}
+	// The root and the output may be spelled differently, one relative and
+	// the other absolute.
+	path, output := absPath(path), absPath(s.config.Output)
+	if path == output {
+		return true
+	}
+	if filepath.Dir(path) != filepath.Dir(output) {
+		return false
+	}
+
+	name, base := filepath.Base(path), filepath.Base(output)
+	if i := strings.LastIndex(name, ".tmp-"); strings.HasPrefix(name, ".") && i > 0 {
+		name = name[1:i]
+	}
+	if name == base {
+		return true
+	}
+	ext := filepath.Ext(base)
+	n, ok := strings.CutPrefix(strings.TrimSuffix(name, ext), strings.TrimSuffix(base, ext)+".part")
+	return ok && strings.HasSuffix(name, ext) && n != "" && strings.Trim(n, "0123456789") == ""
+}
+
+// absPath returns the absolute form of path, or path cleaned when the
+// working directory is unknown.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+func skipIfDir(d fs.DirEntry) error {
+	if d.IsDir() {
 		return fs.SkipDir
 	}
 	return nil
 }
 
 func (s *Scanner) shouldIgnore(path string) bool {
-	// Ignore output file
-	if filepath.Clean(path) == filepath.Clean(s.config.Output) {
+	if s.isOutput(path) {
 		return true
 	}
 
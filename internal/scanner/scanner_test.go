@@ -1,11 +1,17 @@
 package scanner
 
 import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
+	"github.com/nouuu/gopeek/internal/lang"
 	"github.com/nouuu/gopeek/internal/logger"
 )
 
@@ -134,7 +140,7 @@ func TestScanner_Run(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			log := logger.Default()
 			scanner := New(tmpDir, tt.config, log)
-			err := scanner.Run()
+			err := scanner.Run(context.Background())
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -223,34 +229,30 @@ func TestScanner_shouldIgnore(t *testing.T) {
 }
 
 func TestScanner_processPath(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "scanner-test")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	// Create test files
-	testFile := filepath.Join(tmpDir, "test.txt")
-	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
-		t.Fatal(err)
+	fsys := fstest.MapFS{
+		"test.txt": &fstest.MapFile{Data: []byte("test content")},
 	}
 
 	tests := []struct {
 		name        string
-		setupPath   string
 		testPath    string
 		expectError bool
+		expectKind  ErrorKind
 	}{
 		{
 			name:        "Process existing file",
-			setupPath:   testFile,
-			testPath:    filepath.Join(tmpDir, "test.txt"),
+			testPath:    "test.txt",
 			expectError: false,
 		},
 		{
 			name:        "Process non-existent file",
-			setupPath:   testFile,
-			testPath:    filepath.Join(tmpDir, "nonexistent.txt"),
+			testPath:    "nonexistent.txt",
+			expectError: false,
+			expectKind:  ErrorRead,
+		},
+		{
+			name:        "Process non-existent root",
+			testPath:    ".",
 			expectError: true,
 		},
 	}
@@ -258,13 +260,17 @@ func TestScanner_processPath(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			log := logger.Default()
-			scanner := New(tmpDir, DefaultConfig(), log)
+			scanner := NewFS("project", fsys, DefaultConfig(), log)
 
-			var fileInfo os.FileInfo
-			var statErr error
-
-			fileInfo, statErr = os.Stat(tt.testPath)
-			err = scanner.processPath(tt.testPath, fileInfo, statErr)
+			var entry fs.DirEntry
+			info, statErr := fs.Stat(fsys, tt.testPath)
+			if statErr == nil {
+				entry = fs.FileInfoToDirEntry(info)
+			}
+			if tt.testPath == "." {
+				statErr = fs.ErrNotExist
+			}
+			err := scanner.processPath(context.Background(), tt.testPath, entry, statErr)
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -272,6 +278,441 @@ func TestScanner_processPath(t *testing.T) {
 			if !tt.expectError && err != nil {
 				t.Errorf("Expected no error but got: %v", err)
 			}
+
+			errs := scanner.Snapshot().Errors
+			if tt.expectKind == "" && len(errs) != 0 {
+				t.Errorf("Expected no recorded error, got: %v", errs)
+			}
+			if tt.expectKind != "" && (len(errs) != 1 || errs[0].Kind != tt.expectKind) {
+				t.Errorf("Expected one %s error to be recorded, got: %v", tt.expectKind, errs)
+			}
+		})
+	}
+}
+
+func TestNewFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":          &fstest.MapFile{Data: []byte("*.tmp\n")},
+		"main.go":             &fstest.MapFile{Data: []byte("package main")},
+		"cache.tmp":           &fstest.MapFile{Data: []byte("ignored")},
+		"internal/a/a.go":     &fstest.MapFile{Data: []byte("package a")},
+		"internal/a/logo.png": &fstest.MapFile{Data: []byte{0xFF, 0xD8, 0xFF}},
+	}
+
+	scanner := NewFS("project", fsys, DefaultConfig(), logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	output := scanner.Output()
+	content := output.Generate()
+	for _, expect := range []string{
+		"- 📄 [main.go](#-maingo)",
+		"  - 📁 a",
+		"# 📄 internal/a/a.go\n```go\npackage a\n```",
+		"# 📄 internal/a/logo.png\n```\n[binary file]\n```",
+	} {
+		if !strings.Contains(content, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, content)
+		}
+	}
+	if strings.Contains(content, "cache.tmp") {
+		t.Error("Expected .gitignore from fsys to be applied")
+	}
+}
+
+func TestScanner_Symlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "scanner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "real"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "real", "inner.txt"), []byte("inner content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"file-link": filepath.Join("real", "inner.txt"),
+		"dir-link":  "real",
+		"real/loop": "..",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(tmpDir, link)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name              string
+		policy            SymlinkPolicy
+		expectInOutput    []string
+		expectNotInOutput []string
+	}{
+		{
+			name:              "Skip",
+			policy:            SymlinkSkip,
+			expectInOutput:    []string{"inner.txt"},
+			expectNotInOutput: []string{"file-link", "dir-link", "🔗"},
+		},
+		{
+			name:              "List",
+			policy:            SymlinkList,
+			expectInOutput:    []string{"- 🔗 file-link → real/inner.txt", "- 🔗 dir-link → real", "- 🔗 loop → .."},
+			expectNotInOutput: []string{"# 📄 file-link", "dir-link/inner.txt"},
+		},
+		{
+			name:           "Follow",
+			policy:         SymlinkFollow,
+			expectInOutput: []string{"- 🔗 [file-link](#", "# 📄 file-link", "# 📄 dir-link/inner.txt", "- 🔗 loop → .. (loop)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				Output:   filepath.Join(tmpDir, "output.md"),
+				Symlinks: tt.policy,
+			}
+			scanner := New(tmpDir, cfg, logger.Default())
+			if err := scanner.Run(context.Background()); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			content, err := os.ReadFile(cfg.Output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expect := range tt.expectInOutput {
+				if !strings.Contains(string(content), expect) {
+					t.Errorf("Expected output to contain %q", expect)
+				}
+			}
+			for _, notExpect := range tt.expectNotInOutput {
+				if strings.Contains(string(content), notExpect) {
+					t.Errorf("Expected output to not contain %q", notExpect)
+				}
+			}
+		})
+	}
+}
+
+func TestScanner_Archive(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "scanner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archivePath := filepath.Join(tmpDir, "release.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	testFiles := map[string]string{
+		"release/main.go":        "package main",
+		"release/docs/README.md": "# Release",
+		"debug.log":              "should be ignored",
+		".gitignore":             "*.log",
+	}
+	for name, content := range testFiles {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cfg := Config{Output: filepath.Join(tmpDir, "output.md")}
+	if err := New(archivePath, cfg, logger.Default()).Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	content, err := os.ReadFile(cfg.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{"- 📁 release", "  - 📄 [main.go](#", "# 📄 release/docs/README.md", "# Release"} {
+		if !strings.Contains(string(content), expect) {
+			t.Errorf("Expected output to contain %q", expect)
+		}
+	}
+	if strings.Contains(string(content), "debug.log") {
+		t.Error("Expected archive .gitignore to be applied")
+	}
+}
+
+func TestScanner_ScanCanceled(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     &fstest.MapFile{Data: []byte("a")},
+		"dir/b.txt": &fstest.MapFile{Data: []byte("b")},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scanner := NewFS("project", fsys, DefaultConfig(), logger.Default())
+	err := scanner.Scan(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if len(scanner.Snapshot().Entries) != 0 {
+		t.Errorf("Expected no entries after cancellation, got %d", len(scanner.Snapshot().Entries))
+	}
+}
+
+// statOnlyFS fails to open regular files, so that reading them is an error.
+type statOnlyFS struct {
+	fstest.MapFS
+}
+
+func (f statOnlyFS) Open(name string) (fs.File, error) {
+	if file, ok := f.MapFS[name]; ok && !file.Mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.Open(name)
+}
+
+func TestScanner_SkipContent(t *testing.T) {
+	fsys := statOnlyFS{fstest.MapFS{
+		"main.go":     &fstest.MapFile{Data: []byte("package main")},
+		"docs/api.md": &fstest.MapFile{Data: []byte("# API")},
+	}}
+
+	config := DefaultConfig()
+	config.SkipContent = true
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := scanner.Snapshot()
+	if len(snapshot.Errors) != 0 {
+		t.Errorf("Expected no file to be read, got errors: %v", snapshot.Errors)
+	}
+	if len(snapshot.Entries) != 3 || len(snapshot.Files) != 0 {
+		t.Errorf("Expected 3 entries and no files, got %d and %d", len(snapshot.Entries), len(snapshot.Files))
+	}
+	if snapshot.Stats.Files != 2 || snapshot.Stats.Bytes != 17 || snapshot.Stats.Lines != 0 {
+		t.Errorf("Unexpected stats: %+v", snapshot.Stats)
+	}
+}
+
+func TestScanner_MaxDepth(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":                 &fstest.MapFile{Data: []byte("package main")},
+		"vendor/lib/lib.go":       &fstest.MapFile{Data: []byte("package lib")},
+		"vendor/lib/deep/deep.go": &fstest.MapFile{Data: []byte("package deep")},
+		"vendor/lib/cache.tmp":    &fstest.MapFile{Data: []byte("ignored")},
+		"vendor/modules.txt":      &fstest.MapFile{Data: []byte("# lib")},
+	}
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		expected []string
+	}{
+		{
+			name:     "No limit",
+			maxDepth: 0,
+			expected: []string{"main.go", "vendor", "vendor/lib", "vendor/lib/deep", "vendor/lib/deep/deep.go", "vendor/lib/lib.go", "vendor/modules.txt"},
+		},
+		{
+			name:     "Root entries only",
+			maxDepth: 1,
+			expected: []string{"main.go", "vendor (3 files, 28 B)"},
+		},
+		{
+			name:     "Two levels",
+			maxDepth: 2,
+			expected: []string{"main.go", "vendor", "vendor/lib (2 files, 23 B)", "vendor/modules.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.IgnorePatterns = []string{"vendor/lib/cache.tmp"}
+			config.MaxDepth = tt.maxDepth
+			scanner := NewFS("project", fsys, config, logger.Default())
+			if err := scanner.Scan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			var entries []string
+			for _, entry := range scanner.Snapshot().Entries {
+				entries = append(entries, entry.Path+collapsedSummary(entry))
+			}
+			if strings.Join(entries, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Entries = %v, want %v", entries, tt.expected)
+			}
+			for _, file := range scanner.Snapshot().Files {
+				if strings.Count(file.Path, "/") >= tt.maxDepth && tt.maxDepth > 0 {
+					t.Errorf("Unexpected content below the depth limit: %s", file.Path)
+				}
+			}
 		})
 	}
 }
+
+func TestScanner_CollapseThreshold(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":             &fstest.MapFile{Data: []byte("package main")},
+		"fixtures/a.json":     &fstest.MapFile{Data: []byte("{}")},
+		"fixtures/b.json":     &fstest.MapFile{Data: []byte("{}")},
+		"fixtures/c/d.json":   &fstest.MapFile{Data: []byte("{}")},
+		"migrations/001.sql":  &fstest.MapFile{Data: []byte("-- 1")},
+		"migrations/002.sql":  &fstest.MapFile{Data: []byte("-- 2")},
+		"migrations/003.sql":  &fstest.MapFile{Data: []byte("-- 3")},
+		"internal/one/one.go": &fstest.MapFile{Data: []byte("package one")},
+		"internal/two/two.go": &fstest.MapFile{Data: []byte("package two")},
+	}
+
+	tests := []struct {
+		name     string
+		expand   []string
+		expected []string
+	}{
+		{
+			name:     "Collapsed",
+			expected: []string{"fixtures (3 files, 6 B)", "internal", "main.go", "migrations (3 files, 12 B)"},
+		},
+		{
+			name:     "Explicitly expanded",
+			expand:   []string{"migrations"},
+			expected: []string{"fixtures (3 files, 6 B)", "internal", "main.go", "migrations", "migrations/001.sql", "migrations/002.sql", "migrations/003.sql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.CollapseThreshold = 2
+			config.ExpandPatterns = tt.expand
+			scanner := NewFS("project", fsys, config, logger.Default())
+			if err := scanner.Scan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			var entries []string
+			for _, entry := range scanner.Snapshot().Entries {
+				if entry.Depth == 0 || strings.HasPrefix(entry.Path, "migrations/") {
+					entries = append(entries, entry.Path+collapsedSummary(entry))
+				}
+			}
+			if strings.Join(entries, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Entries = %v, want %v", entries, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanner_CollapseIgnoredEntries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"logs/1.log":   &fstest.MapFile{Data: []byte("one")},
+		"logs/2.log":   &fstest.MapFile{Data: []byte("two")},
+		"logs/3.log":   &fstest.MapFile{Data: []byte("three")},
+		"logs/main.go": &fstest.MapFile{Data: []byte("package main")},
+	}
+	config := DefaultConfig()
+	config.CollapseThreshold = 2
+	config.IgnorePatterns = []string{"**/*.log"}
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := scanner.Snapshot()
+	if len(snapshot.Entries) == 0 || snapshot.Entries[0].Collapsed {
+		t.Errorf("Expected ignored entries to be left out of the threshold, got %+v", snapshot.Entries)
+	}
+	if len(snapshot.Files) != 1 || snapshot.Files[0].Path != "logs/main.go" {
+		t.Errorf("Expected logs/main.go to be included, got %+v", snapshot.Files)
+	}
+}
+
+func TestScanner_Outline(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":   &fstest.MapFile{Data: []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")},
+		"broken.go": &fstest.MapFile{Data: []byte("package broken\n\nfunc {\n")},
+		"sliced.go": &fstest.MapFile{Data: []byte("package sliced\n\nfunc F() {\n\tprintln()\n}\n")},
+		"notes.md":  &fstest.MapFile{Data: []byte("# Notes\n")},
+		"tool.py":   &fstest.MapFile{Data: []byte("def run():\n    return 1\n")},
+		"data.txt":  &fstest.MapFile{Data: []byte("raw data\n")},
+	}
+	config := DefaultConfig()
+	config.Outline = true
+	config.Summarizers = map[string]lang.Summarizer{
+		"txt": lang.SummarizerFunc(func(name, content string) (string, error) {
+			return strings.ToUpper(content), nil
+		}),
+	}
+	config.Slices = []Slice{{Path: "sliced.go", Lines: LineRange{Start: 3, End: 5}}}
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	if err := (Markdown{Lines: true}).Render(&buf, scanner.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	for _, expect := range []string{
+		// Line counts are those of the files, not of their outline.
+		"[main.go](#-maingo-outline) (5 lines)",
+		"# 📄 main.go (outline)\n```go\npackage main\n\nfunc main()\n\n```",
+		"# 📄 broken.go\n```go\npackage broken\n\nfunc {\n\n```",
+		"# 📄 sliced.go\n```go\n// ... lines 1-2 elided ...\nfunc F() {\n\tprintln()\n}\n```",
+		"# 📄 notes.md\n```md\n# Notes\n\n```",
+		"# 📄 tool.py (outline)\n```py\ndef run():\n    ...\n\n```",
+		"# 📄 data.txt (outline)\n```txt\nRAW DATA\n\n```",
+	} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+}
+
+func TestScanner_Minify(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":   &fstest.MapFile{Data: []byte("// Package main.\npackage main\n\n\n// main runs.\nfunc main() {\n\tprintln(\"// kept\") // dropped\n}\n")},
+		"sliced.go": &fstest.MapFile{Data: []byte("package sliced\n\n// F does nothing.\nfunc F() {}\n")},
+		"notes.md":  &fstest.MapFile{Data: []byte("# Notes\n\n\n<!-- kept -->\n")},
+	}
+	config := DefaultConfig()
+	config.Minify = true
+	config.Slices = []Slice{{Path: "sliced.go"}}
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make(map[string]string)
+	for _, file := range scanner.Snapshot().Files {
+		contents[file.Path] = file.Content
+	}
+	expected := map[string]string{
+		"main.go":   "package main\n\nfunc main() {\n\tprintln(\"// kept\")\n}\n",
+		"sliced.go": "package sliced\n\n// F does nothing.\nfunc F() {}\n",
+		"notes.md":  "# Notes\n\n\n<!-- kept -->\n",
+	}
+	for name, want := range expected {
+		if contents[name] != want {
+			t.Errorf("Content of %s = %q, want %q", name, contents[name], want)
+		}
+	}
+	if lines := scanner.Snapshot().Stats.Lines; lines != 16 {
+		t.Errorf("Expected stats on the original content (16 lines), got %d", lines)
+	}
+}
@@ -0,0 +1,222 @@
+package scanner
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of 1-based line numbers. An End of 0 means
+// the last line of the file.
+type LineRange struct {
+	Start, End int
+}
+
+// Slice selects the part of a file to include, either a range of lines or a
+// declaration. The rest of the file is elided.
+type Slice struct {
+	Path   string // slash-separated path of the file, or its last elements
+	Lines  LineRange
+	Symbol string // Go declaration: Name for functions, types, variables and constants, Type.Method for methods
+}
+
+// ParseSlice parses a slice written as path:start-end, path:line, path:start-
+// or path#Symbol.
+func ParseSlice(value string) (Slice, error) {
+	if i := strings.LastIndex(value, "#"); i >= 0 {
+		slice := Slice{Path: cleanSlicePath(value[:i]), Symbol: value[i+1:]}
+		if slice.Path == "" || slice.Symbol == "" {
+			return Slice{}, fmt.Errorf("invalid slice %q (expected path#Symbol)", value)
+		}
+		return slice, nil
+	}
+
+	i := strings.LastIndex(value, ":")
+	if i < 0 {
+		return Slice{}, fmt.Errorf("invalid slice %q (expected path:start-end or path#Symbol)", value)
+	}
+	lines, err := parseLineRange(value[i+1:])
+	if err != nil || cleanSlicePath(value[:i]) == "" {
+		return Slice{}, fmt.Errorf("invalid slice %q (expected path:start-end or path#Symbol)", value)
+	}
+	return Slice{Path: cleanSlicePath(value[:i]), Lines: lines}, nil
+}
+
+func cleanSlicePath(value string) string {
+	value = strings.TrimPrefix(path.Clean(strings.ReplaceAll(value, "\\", "/")), "./")
+	if value == "." {
+		return ""
+	}
+	return value
+}
+
+func parseLineRange(value string) (LineRange, error) {
+	startValue, endValue, isRange := strings.Cut(value, "-")
+	start, err := strconv.Atoi(startValue)
+	if err != nil || start < 1 {
+		return LineRange{}, fmt.Errorf("invalid start line %q", startValue)
+	}
+	if !isRange {
+		return LineRange{Start: start, End: start}, nil
+	}
+	if endValue == "" {
+		return LineRange{Start: start}, nil
+	}
+	end, err := strconv.Atoi(endValue)
+	if err != nil || end < start {
+		return LineRange{}, fmt.Errorf("invalid end line %q", endValue)
+	}
+	return LineRange{Start: start, End: end}, nil
+}
+
+// Matches reports whether the slice applies to the file name.
+func (s Slice) Matches(name string) bool {
+	return name == s.Path || strings.HasSuffix(name, "/"+s.Path)
+}
+
+// sliceRanges returns the ranges of content selected by the slices matching
+// the file name, merged and sorted, or nil when the whole file is included.
+func sliceRanges(name string, content string, slices []Slice) ([]LineRange, error) {
+	lineCount := countLines(content)
+	var ranges []LineRange
+	for _, slice := range slices {
+		if !slice.Matches(name) {
+			continue
+		}
+
+		lines := slice.Lines
+		if slice.Symbol != "" {
+			var err error
+			if lines, err = symbolRange(name, content, slice.Symbol); err != nil {
+				return nil, err
+			}
+		}
+		if lines.End == 0 || lines.End > lineCount {
+			lines.End = lineCount
+		}
+		if lines.Start > lines.End {
+			return nil, fmt.Errorf("lines %d-%d are out of the %d lines of %s", slice.Lines.Start, slice.Lines.End, lineCount, name)
+		}
+		ranges = append(ranges, lines)
+	}
+	return mergeRanges(ranges), nil
+}
+
+// mergeRanges sorts ranges and merges the ones that overlap or touch.
+func mergeRanges(ranges []LineRange) []LineRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	merged := []LineRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End+1 {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// symbolRange returns the lines of the Go declaration symbol in content,
+// including its doc comment.
+func symbolRange(name string, content string, symbol string) (LineRange, error) {
+	if path.Ext(name) != ".go" {
+		return LineRange{}, fmt.Errorf("cannot find %s in %s: symbol slices are only supported for Go files", symbol, name)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, content, parser.ParseComments)
+	if err != nil {
+		return LineRange{}, fmt.Errorf("error parsing %s: %w", name, err)
+	}
+
+	lines := func(doc *ast.CommentGroup, node ast.Node) LineRange {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return LineRange{Start: fset.Position(start).Line, End: fset.Position(node.End()).Line}
+	}
+
+	typeName, method, isMethod := strings.Cut(symbol, ".")
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			receiver := receiverType(decl)
+			if isMethod && receiver == typeName && decl.Name.Name == method ||
+				!isMethod && receiver == "" && decl.Name.Name == symbol {
+				return lines(decl.Doc, decl), nil
+			}
+		case *ast.GenDecl:
+			if isMethod {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				if !declares(spec, symbol) {
+					continue
+				}
+				if len(decl.Specs) == 1 {
+					return lines(decl.Doc, decl), nil
+				}
+				return lines(specDoc(spec), spec), nil
+			}
+		}
+	}
+	return LineRange{}, fmt.Errorf("symbol %s not found in %s", symbol, name)
+}
+
+// receiverType returns the name of the receiver type of a method, or an
+// empty string for functions.
+func receiverType(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	expr := decl.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func declares(spec ast.Spec, symbol string) bool {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Name.Name == symbol
+	case *ast.ValueSpec:
+		for _, name := range spec.Names {
+			if name.Name == symbol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Doc
+	case *ast.ValueSpec:
+		return spec.Doc
+	}
+	return nil
+}
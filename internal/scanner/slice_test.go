@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestParseSlice(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    Slice
+		expectError bool
+	}{
+		{value: "internal/scanner/types.go:20-80", expected: Slice{Path: "internal/scanner/types.go", Lines: LineRange{Start: 20, End: 80}}},
+		{value: "./main.go:42", expected: Slice{Path: "main.go", Lines: LineRange{Start: 42, End: 42}}},
+		{value: "main.go:100-", expected: Slice{Path: "main.go", Lines: LineRange{Start: 100}}},
+		{value: "scanner.go#Scanner.Run", expected: Slice{Path: "scanner.go", Symbol: "Scanner.Run"}},
+		{value: "main.go", expectError: true},
+		{value: "main.go:0-10", expectError: true},
+		{value: "main.go:30-10", expectError: true},
+		{value: "main.go:a-b", expectError: true},
+		{value: "main.go#", expectError: true},
+		{value: ":1-2", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			slice, err := ParseSlice(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", slice)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slice != tt.expected {
+				t.Errorf("ParseSlice() = %+v, want %+v", slice, tt.expected)
+			}
+		})
+	}
+}
+
+const sliceSource = `package demo
+
+// Scanner scans.
+type Scanner struct {
+	root string
+}
+
+// Run runs the scan.
+func (s *Scanner) Run() error {
+	return nil
+}
+
+func Run() {}
+
+const (
+	A = 1
+	// B is documented.
+	B = 2
+)
+`
+
+func TestSliceRanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		slices      []Slice
+		expected    []LineRange
+		expectError bool
+	}{
+		{name: "No matching slice", slices: []Slice{{Path: "other.go", Lines: LineRange{Start: 1, End: 2}}}},
+		{name: "Line range by suffix", slices: []Slice{{Path: "demo.go", Lines: LineRange{Start: 3, End: 4}}}, expected: []LineRange{{Start: 3, End: 4}}},
+		{name: "Open range", slices: []Slice{{Path: "pkg/demo.go", Lines: LineRange{Start: 17}}}, expected: []LineRange{{Start: 17, End: 19}}},
+		{name: "Method", slices: []Slice{{Path: "demo.go", Symbol: "Scanner.Run"}}, expected: []LineRange{{Start: 8, End: 11}}},
+		{name: "Function", slices: []Slice{{Path: "demo.go", Symbol: "Run"}}, expected: []LineRange{{Start: 13, End: 13}}},
+		{name: "Type", slices: []Slice{{Path: "demo.go", Symbol: "Scanner"}}, expected: []LineRange{{Start: 3, End: 6}}},
+		{name: "Grouped constant", slices: []Slice{{Path: "demo.go", Symbol: "B"}}, expected: []LineRange{{Start: 17, End: 18}}},
+		{
+			name:     "Merged ranges",
+			slices:   []Slice{{Path: "demo.go", Symbol: "Scanner.Run"}, {Path: "demo.go", Lines: LineRange{Start: 1, End: 1}}, {Path: "demo.go", Lines: LineRange{Start: 5, End: 7}}},
+			expected: []LineRange{{Start: 1, End: 1}, {Start: 5, End: 11}},
+		},
+		{name: "Unknown symbol", slices: []Slice{{Path: "demo.go", Symbol: "Missing"}}, expectError: true},
+		{name: "Out of range", slices: []Slice{{Path: "demo.go", Lines: LineRange{Start: 50, End: 60}}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := sliceRanges("pkg/demo.go", sliceSource, tt.slices)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %v", ranges)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ranges, tt.expected) {
+				t.Errorf("sliceRanges() = %v, want %v", ranges, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanner_Slices(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pkg/demo.go": &fstest.MapFile{Data: []byte(sliceSource)},
+		"notes.txt":   &fstest.MapFile{Data: []byte("one\ntwo\nthree\n")},
+		"main.go":     &fstest.MapFile{Data: []byte("package main\n")},
+	}
+	config := DefaultConfig()
+	config.Slices = []Slice{
+		{Path: "demo.go", Symbol: "Scanner.Run"},
+		{Path: "notes.txt", Symbol: "Missing"},
+	}
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	if err := (Markdown{LineNumbers: true}).Render(&buf, scanner.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	for _, expect := range []string{
+		"```\n... lines 1-7 elided ...\n 8 | // Run runs the scan.\n 9 | func (s *Scanner) Run() error {\n10 | \treturn nil\n11 | }\n... lines 12-19 elided ...\n```",
+		"```\n1 | package main\n\n```",
+	} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+	if strings.Contains(output, "three") {
+		t.Errorf("Expected notes.txt to be left out, got:\n%s", output)
+	}
+	errs := scanner.Snapshot().Errors
+	if len(errs) != 1 || errs[0].Kind != ErrorSlice || errs[0].Path != "project/notes.txt" {
+		t.Errorf("Errors = %v, want a slice error for project/notes.txt", errs)
+	}
+}
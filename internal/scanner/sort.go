@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortOrder controls the order of sibling entries in the structure and of
+// the file contents that follow it.
+type SortOrder string
+
+const (
+	SortName      SortOrder = "name"       // lexical order, as walked
+	SortDirsFirst SortOrder = "dirs-first" // directories before files, then by name
+	SortSize      SortOrder = "size"       // largest first, directories by total size
+	SortMtime     SortOrder = "mtime"      // most recently modified first, directories by newest file
+)
+
+func ParseSortOrder(value string) (SortOrder, error) {
+	switch order := SortOrder(value); order {
+	case SortName, SortDirsFirst, SortSize, SortMtime:
+		return order, nil
+	}
+	return "", fmt.Errorf("invalid sort order %q (expected name, dirs-first, size or mtime)", value)
+}
+
+// sortSnapshot reorders the entries of snapshot as a tree, siblings being
+// sorted by order, and the files to follow the entries. Ties are broken by
+// name so that identical inputs always give the same order.
+func sortSnapshot(snapshot *Snapshot, order SortOrder) {
+	children := make(map[string][]int)
+	for i, entry := range snapshot.Entries {
+		parent := path.Dir(entry.Path)
+		children[parent] = append(children[parent], i)
+	}
+
+	// Followed directory links are not directories themselves but have
+	// children, they sort as directories.
+	isDir := func(entry Entry) bool {
+		return entry.IsDir || entry.Collapsed || len(children[entry.Path]) > 0
+	}
+
+	// Directories are compared on the totals of the files below them.
+	sizes := make(map[string]int64)
+	mtimes := make(map[string]time.Time)
+	for _, entry := range snapshot.Entries {
+		if isDir(entry) {
+			continue
+		}
+		for dir := path.Dir(entry.Path); dir != "."; dir = path.Dir(dir) {
+			sizes[dir] += entry.Size
+			if entry.ModTime.After(mtimes[dir]) {
+				mtimes[dir] = entry.ModTime
+			}
+		}
+	}
+	size := func(entry Entry) int64 {
+		if entry.Collapsed {
+			return entry.Bytes
+		}
+		if isDir(entry) {
+			return sizes[entry.Path]
+		}
+		return entry.Size
+	}
+	mtime := func(entry Entry) time.Time {
+		if isDir(entry) && mtimes[entry.Path].After(entry.ModTime) {
+			return mtimes[entry.Path]
+		}
+		return entry.ModTime
+	}
+
+	less := func(a, b Entry) bool {
+		switch order {
+		case SortDirsFirst:
+			if isDir(a) != isDir(b) {
+				return isDir(a)
+			}
+		case SortSize:
+			if size(a) != size(b) {
+				return size(a) > size(b)
+			}
+		case SortMtime:
+			if !mtime(a).Equal(mtime(b)) {
+				return mtime(a).After(mtime(b))
+			}
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return strings.Compare(a.Path, b.Path) < 0
+	}
+
+	sorted := make([]Entry, 0, len(snapshot.Entries))
+	visited := make([]bool, len(snapshot.Entries))
+	var visit func(parent string)
+	visit = func(parent string) {
+		indexes := children[parent]
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return less(snapshot.Entries[indexes[i]], snapshot.Entries[indexes[j]])
+		})
+		for _, i := range indexes {
+			sorted = append(sorted, snapshot.Entries[i])
+			visited[i] = true
+			visit(snapshot.Entries[i].Path)
+		}
+	}
+	visit(".")
+	// Keep entries whose parent is not part of the structure where they were.
+	for i, entry := range snapshot.Entries {
+		if !visited[i] {
+			sorted = append(sorted, entry)
+		}
+	}
+	snapshot.Entries = sorted
+
+	position := make(map[string]int, len(sorted))
+	for i, entry := range sorted {
+		position[entry.Path] = i
+	}
+	sort.SliceStable(snapshot.Files, func(i, j int) bool {
+		return position[snapshot.Files[i].Path] < position[snapshot.Files[j].Path]
+	})
+}
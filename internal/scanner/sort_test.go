@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	for _, value := range []string{"name", "dirs-first", "size", "mtime"} {
+		if order, err := ParseSortOrder(value); err != nil || string(order) != value {
+			t.Errorf("ParseSortOrder(%q) = %q, %v", value, order, err)
+		}
+	}
+	if _, err := ParseSortOrder("random"); err == nil {
+		t.Error("Expected error for invalid order")
+	}
+}
+
+func TestScanner_Sort(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"b.txt":       &fstest.MapFile{Data: []byte("bb"), ModTime: base.Add(3 * time.Hour)},
+		"a/z.txt":     &fstest.MapFile{Data: []byte("z"), ModTime: base.Add(1 * time.Hour)},
+		"a/y.txt":     &fstest.MapFile{Data: []byte("yyyyyy"), ModTime: base.Add(2 * time.Hour)},
+		"c.txt":       &fstest.MapFile{Data: []byte("cccc"), ModTime: base},
+		"d/e/big.txt": &fstest.MapFile{Data: []byte("0123456789"), ModTime: base},
+	}
+
+	tests := []struct {
+		order    SortOrder
+		expected string
+	}{
+		{order: SortName, expected: "a,a/y.txt,a/z.txt,b.txt,c.txt,d,d/e,d/e/big.txt"},
+		{order: SortDirsFirst, expected: "a,a/y.txt,a/z.txt,d,d/e,d/e/big.txt,b.txt,c.txt"},
+		{order: SortSize, expected: "d,d/e,d/e/big.txt,a,a/y.txt,a/z.txt,c.txt,b.txt"},
+		{order: SortMtime, expected: "b.txt,a,a/y.txt,a/z.txt,c.txt,d,d/e,d/e/big.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			scanner := NewFS("project", fsys, Config{Sort: tt.order}, logger.Default())
+			if err := scanner.Scan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			snapshot := scanner.Snapshot()
+
+			var paths []string
+			for _, entry := range snapshot.Entries {
+				paths = append(paths, entry.Path)
+			}
+			if result := strings.Join(paths, ","); result != tt.expected {
+				t.Errorf("Entries = %s, want %s", result, tt.expected)
+			}
+
+			var files, expectedFiles []string
+			for _, file := range snapshot.Files {
+				files = append(files, file.Path)
+			}
+			for _, p := range paths {
+				if strings.HasSuffix(p, ".txt") {
+					expectedFiles = append(expectedFiles, p)
+				}
+			}
+			if strings.Join(files, ",") != strings.Join(expectedFiles, ",") {
+				t.Errorf("Files = %v, want %v", files, expectedFiles)
+			}
+		})
+	}
+}
+
+func TestScanner_DeterministicOutput(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":         &fstest.MapFile{Data: []byte("package main")},
+		"internal/a/a.go": &fstest.MapFile{Data: []byte("package a")},
+		"internal/b.md":   &fstest.MapFile{Data: []byte("# b")},
+		"logo.png":        &fstest.MapFile{Data: []byte{0xFF, 0xD8}},
+	}
+
+	render := func() string {
+		scanner := NewFS("project", fsys, Config{Sort: SortSize}, logger.Default())
+		if err := scanner.Scan(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var buf strings.Builder
+		if err := (Markdown{Summary: true}).Render(&buf, scanner.Snapshot()); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	first := render()
+	for i := 0; i < 5; i++ {
+		if output := render(); output != first {
+			t.Fatalf("Expected byte-identical output, got:\n%s\nthen:\n%s", first, output)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitSize is the maximum size of each part of a split document, in bytes
+// or in estimated tokens.
+type SplitSize struct {
+	Limit  int64
+	Tokens bool // Limit counts tokens, estimated at 4 characters each
+}
+
+// ParseSplitSize parses a size in bytes, such as 500000, 512KB or 2MB, or in
+// tokens, such as 100k tokens or 8000t.
+func ParseSplitSize(value string) (SplitSize, error) {
+	invalid := fmt.Errorf("invalid split size %q (expected bytes such as 512KB or tokens such as 100k tokens)", value)
+	s := strings.ToLower(strings.ReplaceAll(value, " ", ""))
+
+	var size SplitSize
+	for _, suffix := range []string{"tokens", "token", "t"} {
+		if trimmed, ok := strings.CutSuffix(s, suffix); ok {
+			s, size.Tokens = trimmed, true
+			break
+		}
+	}
+	if !size.Tokens {
+		s = strings.TrimSuffix(s, "b")
+	}
+
+	// Byte multiples are binary, as in FormatBytes, token counts decimal.
+	unit := int64(1024)
+	if size.Tokens {
+		unit = 1000
+	}
+	multiplier := int64(1)
+	for i, prefix := range []string{"k", "m", "g"} {
+		if trimmed, ok := strings.CutSuffix(s, prefix); ok {
+			s = trimmed
+			for range i + 1 {
+				multiplier *= unit
+			}
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return SplitSize{}, invalid
+	}
+	size.Limit = n * multiplier
+	return size, nil
+}
+
+func (s SplitSize) String() string {
+	if s.Tokens {
+		return fmt.Sprintf("%d tokens", s.Limit)
+	}
+	return FormatBytes(s.Limit)
+}
+
+// measure returns the size of text in the unit of the limit.
+func (s SplitSize) measure(text string) int64 {
+	if s.Tokens {
+		return estimateTokens(text)
+	}
+	return int64(len(text))
+}
+
+// estimateTokens approximates the number of tokens of text for the usual
+// LLM tokenizers, which average about 4 characters per token.
+func estimateTokens(text string) int64 {
+	return int64(utf8.RuneCountInString(text)+3) / 4
+}
+
+// PartPath returns the path of the nth part of the document at path:
+// project_knowledge.part1.md for project_knowledge.md.
+func PartPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// RenderParts renders snapshots as a sequence of documents no larger than
+// limit. Each part starts with its number and an index of the files it
+// holds. Files are never split across parts, unless a file alone exceeds the
+// limit: it is then cut between lines into pieces that fit. A structure too
+// large for a part is cut between entries likewise.
+func (m Markdown) RenderParts(limit SplitSize, snapshots ...*Snapshot) []string {
+	s := &splitter{m: m, limit: limit, multiRoot: len(snapshots) > 1}
+	for _, snapshot := range snapshots {
+		s.addSnapshot(snapshot)
+	}
+
+	if m.Summary {
+		var stats Stats
+		for _, snapshot := range snapshots {
+			stats.Merge(snapshot.Stats)
+		}
+		s.root, s.inFiles = nil, false
+		summary := strings.TrimPrefix(renderSummary(stats), "\n")
+		s.add(func(slugs *slugger) (string, string) {
+			slugs.slug("Scan Summary")
+			return "", summary
+		})
+	}
+	if len(s.parts) == 0 {
+		s.parts = append(s.parts, s.newPart())
+	}
+	return s.render()
+}
+
+// WriteParts renders snapshots into the parts of the document at path, see
+// RenderParts and PartPath, and returns the paths written. Parts left from a
+// previous run that produced more of them are removed.
+func (m Markdown) WriteParts(path string, limit SplitSize, snapshots ...*Snapshot) ([]string, error) {
+	parts := m.RenderParts(limit, snapshots...)
+	paths := make([]string, len(parts))
+	for i, part := range parts {
+		paths[i] = PartPath(path, i+1)
+		if err := WriteOutput(paths[i], []byte(part)); err != nil {
+			return paths[:i], err
+		}
+	}
+	for n := len(parts) + 1; ; n++ {
+		if err := os.Remove(PartPath(path, n)); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return paths, fmt.Errorf("error removing stale part: %w", err)
+			}
+			return paths, nil
+		}
+	}
+}
+
+// renderItem renders a piece of a part with the anchors of the part. It
+// returns the line of the piece in the index of the part, if any, and its
+// text.
+type renderItem func(slugs *slugger) (index, text string)
+
+// part is a document being filled by a splitter.
+type part struct {
+	slugs *slugger
+	index []string
+	body  []string
+	size  int64
+	items int // items added, besides the headings repeated from the previous part
+}
+
+// splitter fills parts in document order, starting a new part when the next
+// item does not fit in the current one.
+type splitter struct {
+	m         Markdown
+	limit     SplitSize
+	multiRoot bool
+	parts     []*part
+
+	// root and inFiles describe where the document is, so that a new part
+	// starts by repeating the title of the root and the contents heading.
+	root    renderItem
+	inFiles bool
+}
+
+func (s *splitter) heading() string {
+	if s.multiRoot {
+		return "##"
+	}
+	return "#"
+}
+
+func (s *splitter) addSnapshot(snapshot *Snapshot) {
+	files := fileIndex(snapshot)
+	s.root, s.inFiles = nil, false
+	if s.multiRoot {
+		title := s.m.icon("📦") + rootName(snapshot.Root)
+		root := func(slugs *slugger) (string, string) {
+			slugs.slug(title)
+			return "- " + title, fmt.Sprintf("# %s\n\n", title)
+		}
+		s.add(root)
+		s.root = root
+	}
+
+	// Parts are read on their own, the structure does not link to the files
+	// of other parts.
+	titles := s.m.sectionTitles(snapshot)
+	for i, section := range s.m.renderSections(snapshot, s.heading(), files, nil) {
+		title, text := titles[i], section+"\n"
+		item := func(slugs *slugger) (string, string) {
+			slugs.slug(title)
+			return "", text
+		}
+		if title == "Project Structure" && s.overhead()+s.measure(item) > s.limit.Limit {
+			s.addStructure(s.m.renderStructure(snapshot, files, nil))
+			continue
+		}
+		s.add(item)
+	}
+	if s.m.SkipContents || len(snapshot.Files) == 0 {
+		return
+	}
+
+	s.add(s.contentsHeading)
+	s.inFiles = true
+	for _, file := range snapshot.Files {
+		s.addFile(file)
+	}
+}
+
+// addStructure adds the lines of a structure section too large for a part
+// in pieces cut between entries, each under the heading of the section.
+func (s *splitter) addStructure(lines []string) {
+	fenced := s.m.treeStructure()
+	if fenced {
+		lines = lines[1 : len(lines)-1]
+	}
+	item := func(title string, lines []string) renderItem {
+		body := strings.Join(lines, "\n")
+		if fenced {
+			body = "```\n" + body + "\n```"
+		}
+		return func(slugs *slugger) (string, string) {
+			slugs.slug(title)
+			return "", fmt.Sprintf("%s %s\n\n%s\n\n", s.heading(), title, body)
+		}
+	}
+
+	// Measure the pieces with the widest label they can get.
+	label := func(k, n int) string { return fmt.Sprintf("Project Structure (%d/%d)", k, n) }
+	available := s.limit.Limit - s.overhead() - s.measure(item(label(len(lines), len(lines)), nil))
+
+	var pieces [][]string
+	var piece []string
+	var size int64
+	for _, line := range lines {
+		lineSize := s.limit.measure(line + "\n")
+		if len(piece) > 0 && size+lineSize > available {
+			pieces = append(pieces, piece)
+			piece, size = nil, 0
+		}
+		piece = append(piece, line)
+		size += lineSize
+	}
+	pieces = append(pieces, piece)
+
+	for k, piece := range pieces {
+		s.add(item(label(k+1, len(pieces)), piece))
+	}
+}
+
+func (s *splitter) contentsHeading(slugs *slugger) (string, string) {
+	slugs.slug("Files Content")
+	return "", s.heading() + " Files Content\n"
+}
+
+// addFile adds the content of file, in pieces when it alone does not fit in
+// a part.
+func (s *splitter) addFile(file File) {
+	body := s.m.renderBody(file)
+	title := s.m.fileHeading(file)
+	if s.overhead()+s.measure(s.fileItem(file, title, body)) <= s.limit.Limit {
+		s.add(s.fileItem(file, title, body))
+		return
+	}
+
+	// Measure the pieces with the widest label they can get.
+	lines := strings.SplitAfter(body, "\n")
+	label := func(k, n int) string { return fmt.Sprintf("%s (%d/%d)", title, k, n) }
+	widest := label(len(lines), len(lines))
+	available := s.limit.Limit - s.overhead() - s.measure(s.fileItem(file, widest, ""))
+
+	var pieces []string
+	var piece strings.Builder
+	var size int64
+	for _, line := range lines {
+		lineSize := s.limit.measure(line)
+		if piece.Len() > 0 && size+lineSize > available {
+			pieces = append(pieces, piece.String())
+			piece.Reset()
+			size = 0
+		}
+		piece.WriteString(line)
+		size += lineSize
+	}
+	pieces = append(pieces, piece.String())
+
+	for k, piece := range pieces {
+		s.add(s.fileItem(file, label(k+1, len(pieces)), strings.TrimSuffix(piece, "\n")))
+	}
+}
+
+func (s *splitter) fileItem(file File, title, body string) renderItem {
+	return func(slugs *slugger) (string, string) {
+		anchor := slugs.slug(title)
+		index := fmt.Sprintf("- [%s](#%s)", title, anchor)
+		if s.multiRoot {
+			index = "  " + index
+		}
+		return index, s.m.renderFile(s.heading(), title, anchor, file, body)
+	}
+}
+
+// measure returns the size item takes in a part, rendered with throwaway
+// anchors.
+func (s *splitter) measure(item renderItem) int64 {
+	index, text := item(newSlugger())
+	return s.itemSize(index, text)
+}
+
+func (s *splitter) itemSize(index, text string) int64 {
+	size := s.limit.measure(text)
+	if index != "" {
+		size += s.limit.measure(index + "\n")
+	}
+	return size
+}
+
+// overhead returns the size taken by the header of a part and the headings
+// it repeats from the previous part.
+func (s *splitter) overhead() int64 {
+	return s.newPart().size
+}
+
+// newPart returns an empty part, starting with the headings the document is
+// in. Its header is measured with the widest part count it can show.
+func (s *splitter) newPart() *part {
+	p := &part{slugs: newSlugger()}
+	// The index, when there is one, is followed by a blank line.
+	p.size = s.limit.measure(partHeader(1, 99999) + "\n\n")
+	if s.root != nil {
+		s.push(p, s.root)
+	}
+	if s.inFiles {
+		s.push(p, s.contentsHeading)
+	}
+	p.items = 0
+	return p
+}
+
+func (s *splitter) push(p *part, item renderItem) {
+	index, text := item(p.slugs)
+	p.append(index, text, s.itemSize(index, text))
+}
+
+func (p *part) append(index, text string, size int64) {
+	if index != "" {
+		p.index = append(p.index, index)
+	}
+	p.body = append(p.body, text)
+	p.size += size
+	p.items++
+}
+
+// add appends item to the current part, or to a new part when it does not
+// fit. An item too large for an empty part gets a part of its own.
+func (s *splitter) add(item renderItem) {
+	if len(s.parts) > 0 {
+		// The anchors taken by an item that does not fit are lost, but no
+		// other item is added to the part after it.
+		current := s.parts[len(s.parts)-1]
+		index, text := item(current.slugs)
+		if size := s.itemSize(index, text); current.items == 0 || current.size+size <= s.limit.Limit {
+			current.append(index, text, size)
+			return
+		}
+	}
+	p := s.newPart()
+	s.parts = append(s.parts, p)
+	s.push(p, item)
+}
+
+func (s *splitter) render() []string {
+	documents := make([]string, len(s.parts))
+	for i, p := range s.parts {
+		var b strings.Builder
+		b.WriteString(partHeader(i+1, len(s.parts)))
+		if len(p.index) > 0 {
+			b.WriteString("\n" + strings.Join(p.index, "\n") + "\n")
+		}
+		b.WriteString("\n" + strings.Join(p.body, ""))
+		documents[i] = strings.TrimRight(b.String(), "\n") + "\n"
+	}
+	return documents
+}
+
+func partHeader(n, total int) string {
+	return fmt.Sprintf("# Part %d of %d\n", n, total)
+}
@@ -0,0 +1,201 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseSplitSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected SplitSize
+		wantErr  bool
+	}{
+		{value: "500000", expected: SplitSize{Limit: 500000}},
+		{value: "512KB", expected: SplitSize{Limit: 512 * 1024}},
+		{value: "2mb", expected: SplitSize{Limit: 2 * 1024 * 1024}},
+		{value: "64k", expected: SplitSize{Limit: 64 * 1024}},
+		{value: "100k tokens", expected: SplitSize{Limit: 100000, Tokens: true}},
+		{value: "8000t", expected: SplitSize{Limit: 8000, Tokens: true}},
+		{value: "1M token", expected: SplitSize{Limit: 1000000, Tokens: true}},
+		{value: "", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-5KB", wantErr: true},
+		{value: "lots", wantErr: true},
+		{value: "1.5MB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := ParseSplitSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSplitSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if size != tt.expected {
+				t.Errorf("ParseSplitSize(%q) = %+v, want %+v", tt.value, size, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	tests := map[string]string{
+		"project_knowledge.md": "project_knowledge.part2.md",
+		"out/context.markdown": "out/context.part2.markdown",
+		"notes":                "notes.part2",
+	}
+	for path, expected := range tests {
+		if got := PartPath(path, 2); got != expected {
+			t.Errorf("PartPath(%q, 2) = %q, want %q", path, got, expected)
+		}
+	}
+}
+
+func splitSnapshot() *Snapshot {
+	return &Snapshot{
+		Root: "project",
+		Entries: []Entry{
+			{Path: "a.txt", Name: "a.txt"},
+			{Path: "b.txt", Name: "b.txt"},
+			{Path: "big.txt", Name: "big.txt"},
+			{Path: "c.txt", Name: "c.txt"},
+		},
+		Files: []File{
+			{Path: "a.txt", Language: "txt", Content: strings.Repeat("a", 200) + "\n"},
+			{Path: "b.txt", Language: "txt", Content: strings.Repeat("b", 200) + "\n"},
+			{Path: "big.txt", Language: "txt", Content: strings.Repeat(strings.Repeat("x", 99)+"\n", 20)},
+			{Path: "c.txt", Language: "txt", Content: "c\n"},
+		},
+	}
+}
+
+func TestMarkdown_RenderParts(t *testing.T) {
+	limit := SplitSize{Limit: 700}
+	parts := Markdown{}.RenderParts(limit, splitSnapshot())
+	if len(parts) < 3 {
+		t.Fatalf("Expected at least 3 parts, got %d", len(parts))
+	}
+
+	for i, part := range parts {
+		if int64(len(part)) > limit.Limit {
+			t.Errorf("Part %d is %d bytes, over the %d limit:\n%s", i+1, len(part), limit.Limit, part)
+		}
+		header := "# Part " + strconv.Itoa(i+1) + " of " + strconv.Itoa(len(parts)) + "\n"
+		if !strings.HasPrefix(part, header) {
+			t.Errorf("Expected part %d to start with %q, got:\n%s", i+1, header, part)
+		}
+	}
+
+	document := strings.Join(parts, "")
+	if !strings.Contains(parts[0], "# Project Structure\n\n- 📄 a.txt\n") {
+		t.Errorf("Expected the structure, without links, in the first part:\n%s", parts[0])
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if count := strings.Count(document, "# 📄 "+name+"\n"); count != 1 {
+			t.Errorf("Expected %s to be rendered whole once, got %d times", name, count)
+		}
+	}
+
+	// big.txt does not fit in a part, it is cut between lines.
+	pieces := strings.Count(document, "# 📄 big.txt (")
+	if pieces < 2 || strings.Count(document, strings.Repeat("x", 99)) != 20 {
+		t.Errorf("Expected big.txt in pieces holding its 20 lines, got:\n%s", document)
+	}
+	for _, part := range parts {
+		if strings.Contains(part, "# 📄 big.txt (") && !strings.Contains(part, "- [📄 big.txt (") {
+			t.Errorf("Expected the index to list the pieces of the part:\n%s", part)
+		}
+		if strings.Contains(part, "<a id=") && !strings.Contains(part, "\n# Files Content\n") {
+			t.Errorf("Expected each part with contents to repeat the contents heading:\n%s", part)
+		}
+	}
+	expected := "\n- [📄 big.txt (1/" + strconv.Itoa(pieces) + ")](#-bigtxt-1" + strconv.Itoa(pieces) + ")\n"
+	if !strings.Contains(document, expected) {
+		t.Errorf("Expected index entry %q, got:\n%s", expected, document)
+	}
+}
+
+func TestMarkdown_RenderPartsStructure(t *testing.T) {
+	snapshot := &Snapshot{Root: "project"}
+	for i := range 100 {
+		name := fmt.Sprintf("file%03d.txt", i)
+		snapshot.Entries = append(snapshot.Entries, Entry{Path: name, Name: name})
+	}
+
+	for _, style := range []StructureStyle{StructureList, StructureTree} {
+		t.Run(string(style), func(t *testing.T) {
+			limit := SplitSize{Limit: 500}
+			parts := Markdown{Structure: style, SkipContents: true}.RenderParts(limit, snapshot)
+			if len(parts) < 3 {
+				t.Fatalf("Expected the structure in at least 3 parts, got %d", len(parts))
+			}
+			for i, part := range parts {
+				if int64(len(part)) > limit.Limit {
+					t.Errorf("Part %d is %d bytes, over the %d limit:\n%s", i+1, len(part), limit.Limit, part)
+				}
+				heading := fmt.Sprintf("# Project Structure (%d/%d)\n\n", i+1, len(parts))
+				if !strings.Contains(part, heading) {
+					t.Errorf("Expected part %d to hold %q, got:\n%s", i+1, heading, part)
+				}
+				if style == StructureTree && strings.Count(part, "```") != 2 {
+					t.Errorf("Expected part %d to hold a whole code block:\n%s", i+1, part)
+				}
+			}
+			document := strings.Join(parts, "")
+			for _, entry := range snapshot.Entries {
+				if strings.Count(document, entry.Name+"\n") != 1 {
+					t.Errorf("Expected %s to be listed once, got:\n%s", entry.Name, document)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdown_RenderPartsMultiRoot(t *testing.T) {
+	api, web := splitSnapshot(), splitSnapshot()
+	api.Root, web.Root = "api", "web"
+	parts := Markdown{Summary: true, SkipStructure: true}.RenderParts(SplitSize{Limit: 1000, Tokens: true}, api, web)
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(parts))
+	}
+	for _, expect := range []string{
+		"# Part 1 of 2\n\n- 📦 api\n  - [📄 a.txt](#-atxt)\n",
+		"\n# 📦 api\n\n## Files Content\n\n<a id=\"-atxt\"></a>\n## 📄 a.txt\n",
+	} {
+		if !strings.Contains(parts[0], expect) {
+			t.Errorf("Expected the first part to contain %q, got:\n%s", expect, parts[0])
+		}
+	}
+	if !strings.Contains(parts[1], "# 📦 web") || !strings.HasSuffix(parts[1], "| Errors | 0 |\n") {
+		t.Errorf("Expected the second part to end with the summary, got:\n%s", parts[1])
+	}
+}
+
+func TestMarkdown_WriteParts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.md")
+	for n := 1; n <= 10; n++ {
+		if err := os.WriteFile(PartPath(path, n), []byte("stale"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Markdown{}.WriteParts(path, SplitSize{Limit: 1 << 20}, splitSnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != PartPath(path, 1) {
+		t.Fatalf("Expected a single part, got %v", paths)
+	}
+	content, err := os.ReadFile(paths[0])
+	if err != nil || !strings.HasPrefix(string(content), "# Part 1 of 1\n") {
+		t.Errorf("Expected the part to be written, got %q, %v", content, err)
+	}
+	if _, err := os.Stat(PartPath(path, 2)); !os.IsNotExist(err) {
+		t.Errorf("Expected stale parts to be removed, got %v", err)
+	}
+}
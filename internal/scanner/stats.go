@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats summarizes what happened during a scan.
+type Stats struct {
+	Files     int // files whose content was included, or listed when content is skipped
+	Ignored   int // paths skipped by ignore patterns
+	Binary    int // files whose content was skipped as binary
+	TooLarge  int // files whose content was skipped for exceeding the size limit
+	Errors    int // paths that could not be read
+	Bytes     int64
+	Lines     int
+	Languages map[string]LanguageStats
+	Elapsed   time.Duration
+}
+
+// LanguageStats holds the totals of included files for one language.
+type LanguageStats struct {
+	Files int
+	Bytes int64
+	Lines int
+}
+
+func (s *Stats) add(language string, size int64, lines int) {
+	if language == "" {
+		language = "other"
+	}
+
+	s.Files++
+	s.Bytes += size
+	s.Lines += lines
+
+	if s.Languages == nil {
+		s.Languages = map[string]LanguageStats{}
+	}
+	lang := s.Languages[language]
+	lang.Files++
+	lang.Bytes += size
+	lang.Lines += lines
+	s.Languages[language] = lang
+}
+
+// Merge adds the counters of other to s, used to summarize several roots.
+func (s *Stats) Merge(other Stats) {
+	s.Files += other.Files
+	s.Ignored += other.Ignored
+	s.Binary += other.Binary
+	s.TooLarge += other.TooLarge
+	s.Errors += other.Errors
+	s.Bytes += other.Bytes
+	s.Lines += other.Lines
+	s.Elapsed += other.Elapsed
+	for name, lang := range other.Languages {
+		if s.Languages == nil {
+			s.Languages = map[string]LanguageStats{}
+		}
+		total := s.Languages[name]
+		total.Files += lang.Files
+		total.Bytes += lang.Bytes
+		total.Lines += lang.Lines
+		s.Languages[name] = total
+	}
+}
+
+// LanguageNames returns the languages seen during the scan, largest first.
+func (s Stats) LanguageNames() []string {
+	names := make([]string, 0, len(s.Languages))
+	for name := range s.Languages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := s.Languages[names[i]], s.Languages[names[j]]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Summary formats the statistics as a short plain text report.
+func (s Stats) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Scan completed in %s\n", s.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(&b, "  files included: %d (%s, %d lines)\n", s.Files, FormatBytes(s.Bytes), s.Lines)
+	fmt.Fprintf(&b, "  skipped: %d ignored, %d binary, %d too large\n", s.Ignored, s.Binary, s.TooLarge)
+	fmt.Fprintf(&b, "  errors: %d\n", s.Errors)
+	for _, name := range s.LanguageNames() {
+		lang := s.Languages[name]
+		fmt.Fprintf(&b, "  %-12s %6d files %8d lines %10s\n", name, lang.Files, lang.Lines, FormatBytes(lang.Bytes))
+	}
+	return b.String()
+}
+
+func countLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	lines := strings.Count(content, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// FormatBytes formats size with a binary unit, e.g. "1.5 KB".
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestScanner_Stats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":      &fstest.MapFile{Data: []byte("package main\n\nfunc main() {}\n")},
+		"util.go":      &fstest.MapFile{Data: []byte("package main")},
+		"README":       &fstest.MapFile{Data: []byte("readme\nsecond line")},
+		"logo.png":     &fstest.MapFile{Data: []byte{0xFF, 0xD8, 0xFF}},
+		"huge.txt":     &fstest.MapFile{Data: make([]byte, maxFileSize+1)},
+		"build/out.go": &fstest.MapFile{Data: []byte("ignored")},
+	}
+
+	cfg := Config{IgnorePatterns: []string{"build"}}
+	scanner := NewFS("project", fsys, cfg, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stats := scanner.Snapshot().Stats
+
+	if stats.Files != 3 || stats.Ignored != 1 || stats.Binary != 1 || stats.TooLarge != 1 || stats.Errors != 0 {
+		t.Errorf("Unexpected counters: %+v", stats)
+	}
+	if stats.Lines != 6 {
+		t.Errorf("Lines = %d, want 6", stats.Lines)
+	}
+	if goStats := stats.Languages["go"]; goStats.Files != 2 || goStats.Lines != 4 {
+		t.Errorf("Unexpected go stats: %+v", goStats)
+	}
+	if other := stats.Languages["other"]; other.Files != 1 || other.Lines != 2 {
+		t.Errorf("Unexpected stats for files without extension: %+v", other)
+	}
+	if stats.Elapsed <= 0 {
+		t.Error("Expected elapsed time to be recorded")
+	}
+}
+
+func TestStats_Merge(t *testing.T) {
+	var total Stats
+	a := Stats{Files: 1, Ignored: 2, Bytes: 10, Lines: 1}
+	a.add("go", 9, 1)
+	b := Stats{Errors: 1}
+	b.add("go", 10, 1)
+	b.add("md", 3, 1)
+
+	total.Merge(a)
+	total.Merge(b)
+
+	if total.Files != 4 || total.Ignored != 2 || total.Errors != 1 {
+		t.Errorf("Unexpected counters: %+v", total)
+	}
+	if total.Languages["go"].Files != 2 || total.Languages["md"].Files != 1 {
+		t.Errorf("Unexpected languages: %+v", total.Languages)
+	}
+	if names := total.LanguageNames(); strings.Join(names, ",") != "go,md" {
+		t.Errorf("LanguageNames() = %v", names)
+	}
+}
+
+func TestStats_Summary(t *testing.T) {
+	var stats Stats
+	stats.add("go", 13, 1)
+	stats.Ignored = 4
+
+	summary := stats.Summary()
+	for _, expect := range []string{"files included: 1 (13 B, 1 lines)", "4 ignored", "errors: 0", "go"} {
+		if !strings.Contains(summary, expect) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", expect, summary)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                "0 B",
+		1023:             "1023 B",
+		1536:             "1.5 KB",
+		48 * 1024 * 1024: "48.0 MB",
+	}
+	for size, expected := range tests {
+		if result := FormatBytes(size); result != expected {
+			t.Errorf("FormatBytes(%d) = %q, want %q", size, result, expected)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// treeGlyphs are the prefixes used to draw a tree: branch, last branch,
+// continued level and finished level.
+type treeGlyphs struct {
+	branch, last, pipe, space string
+}
+
+var (
+	unicodeGlyphs = treeGlyphs{branch: "├── ", last: "└── ", pipe: "│   ", space: "    "}
+	asciiGlyphs   = treeGlyphs{branch: "|-- ", last: "`-- ", pipe: "|   ", space: "    "}
+)
+
+// renderTree renders the entries of snapshot as a tree inside a code block.
+// Entries are expected in depth-first order, as produced by sortSnapshot.
+func (m Markdown) renderTree(snapshot *Snapshot, files map[string]*File) []string {
+	glyphs := unicodeGlyphs
+	if m.Structure == StructureASCII {
+		glyphs = asciiGlyphs
+	}
+
+	// The last entry listed for a parent is its last child.
+	lastChild := make(map[string]int, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		lastChild[path.Dir(entry.Path)] = i
+	}
+
+	lines := make([]string, 0, len(snapshot.Entries)+3)
+	lines = append(lines, "```", rootName(snapshot.Root))
+
+	// open[d] tells whether the ancestor at depth d has siblings left below.
+	var open []bool
+	for i, entry := range snapshot.Entries {
+		isLast := lastChild[path.Dir(entry.Path)] == i
+		if entry.Depth < len(open) {
+			open = open[:entry.Depth]
+		}
+
+		var prefix strings.Builder
+		for _, more := range open {
+			if more {
+				prefix.WriteString(glyphs.pipe)
+			} else {
+				prefix.WriteString(glyphs.space)
+			}
+		}
+		if isLast {
+			prefix.WriteString(glyphs.last)
+		} else {
+			prefix.WriteString(glyphs.branch)
+		}
+
+		lines = append(lines, prefix.String()+m.treeLabel(entry, files[entry.Path]))
+		for len(open) < entry.Depth {
+			open = append(open, false)
+		}
+		open = append(open, !isLast)
+	}
+	return append(lines, "```")
+}
+
+func (m Markdown) treeLabel(entry Entry, file *File) string {
+	switch {
+	case entry.Link != "":
+		return fmt.Sprintf("%s%s → %s%s%s", m.icon("🔗"), entry.Name, linkTarget(entry),
+			m.annotation(entry, file), collapsedSummary(entry))
+	case entry.IsDir:
+		return m.dirName(entry)
+	default:
+		return m.icon("📄") + entry.Name + m.annotation(entry, file)
+	}
+}
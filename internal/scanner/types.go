@@ -1,135 +1,243 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
-	"os"
 	"path/filepath"
-	"strings"
+	"time"
 	"unicode/utf8"
 
+	"github.com/nouuu/gopeek/internal/lang"
 	"github.com/nouuu/gopeek/internal/logger"
+	"github.com/nouuu/gopeek/internal/manifest"
 )
 
+// Snapshot is the result of scanning a root: its structure, in walk order,
+// and the content of its files.
+type Snapshot struct {
+	Root    string
+	Entries []Entry
+	Files   []File
+	Stats   Stats
+	Errors  []*PathError
+
+	// Packages is the dependency graph of the Go packages of the scanned
+	// modules, sorted by import path. It is only collected on demand.
+	Packages []Package
+
+	// Manifests summarize the manifests found at the root, such as go.mod
+	// or package.json. They are only collected on demand.
+	Manifests []manifest.Manifest
+}
+
+// Entry is a node of the project structure.
+type Entry struct {
+	Path    string // slash-separated, relative to the root
+	Name    string
+	Depth   int
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+	Link    string // target of a symbolic link, empty for other entries
+	Broken  bool   // the link target does not exist
+	Loop    bool   // the link was not followed because it leads to a parent
+
+	// Collapsed directories were not walked, Files and Bytes summarize the
+	// files below them.
+	Collapsed bool
+	Files     int
+	Bytes     int64
+}
+
+// File holds the content of a scanned file.
+type File struct {
+	Path     string // slash-separated, relative to the root
+	Language string
+	Content  string
+	Lines    int // lines of the file as read, before any outline or minification
+	Binary   bool
+	Ranges   []LineRange // lines to render when the file is sliced, all lines when empty
+	Outline  bool        // Content holds the declarations of the file only
+	Minified bool        // Content was stripped of its comments and blank lines
+}
+
 type Output struct {
-	structure []string
-	contents  []string
-	log       *logger.Logger
+	snapshot    Snapshot
+	slices      []Slice
+	outline     bool
+	minify      bool
+	summarizers map[string]lang.Summarizer
+	log         *logger.Logger
+
+	// counts records what each file added to the statistics and ignored the
+	// paths skipped by ignore patterns, to recount them after an update.
+	counts  map[string]fileCount
+	ignored map[string]bool
+}
+
+type fileCount struct {
+	language string
+	size     int64
+	lines    int
 }
 
 const maxFileSize = 10 * 1024 * 1024 // 10MB
 
-func (o *Output) AddStructure(path string, info fs.FileInfo, depth int) {
-	indent := strings.Repeat("  ", depth)
-	if info.IsDir() {
-		o.structure = append(o.structure, fmt.Sprintf("%s- 📁 %s", indent, info.Name()))
-	} else {
-		anchor := createAnchor(path)
-		o.structure = append(o.structure, fmt.Sprintf("%s- 📄 [%s](#%s)", indent, info.Name(), anchor))
+func newEntry(name string, info fs.FileInfo, depth int) Entry {
+	return Entry{
+		Path:    name,
+		Name:    info.Name(),
+		Depth:   depth,
+		IsDir:   info.IsDir(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
 	}
 }
 
-func (o *Output) AddContent(path string, relPath string) error {
-	info, err := os.Stat(path)
+func (o *Output) AddStructure(name string, info fs.FileInfo, depth int) {
+	o.AddEntry(newEntry(name, info, depth))
+}
+
+func (o *Output) AddEntry(entry Entry) {
+	o.snapshot.Entries = append(o.snapshot.Entries, entry)
+}
+
+// AddContent adds the content of the file name read from fsys. Reading is
+// interrupted when ctx is done. Binary files are added with a placeholder and
+// reported with an error wrapping errBinary. Files whose slices cannot be
+// resolved are left out and reported with an error wrapping errSlice.
+func (o *Output) AddContent(ctx context.Context, fsys fs.FS, name string) error {
+	info, err := fs.Stat(fsys, name)
 	if err != nil {
 		return fmt.Errorf("error getting file stats: %w", err)
 	}
 
 	if info.Size() > maxFileSize {
-		return fmt.Errorf("file too large (max %dMB): %s", maxFileSize/1024/1024, path)
+		return fmt.Errorf("%w (max %dMB): %s", errTooLarge, maxFileSize/1024/1024, name)
 	}
 
-	isBinary, err := isBinaryFile(path)
+	isBinary, err := isBinaryFile(fsys, name)
 	if err != nil {
 		return fmt.Errorf("error checking if file is binary: %w", err)
 	}
 
-	anchor := createAnchor(path)
-
 	if isBinary {
-		o.contents = append(o.contents, fmt.Sprintf("\n<a id=\"%s\"></a>\n# 📄 %s\n```\n[binary file]\n```\n", anchor, relPath))
-		return nil
+		o.snapshot.Files = append(o.snapshot.Files, File{Path: name, Binary: true})
+		return errBinary
 	}
 
-	content, err := os.ReadFile(path)
+	content, err := readFile(ctx, fsys, name)
 	if err != nil {
-		return fmt.Errorf("error reading file %s: %w", path, err)
+		return fmt.Errorf("error reading file %s: %w", name, err)
+	}
+
+	ext := fileLanguage(name)
+	file := File{Path: name, Language: ext, Content: string(content), Lines: countLines(string(content))}
+	if file.Ranges, err = sliceRanges(name, file.Content, o.slices); err != nil {
+		return fmt.Errorf("%w: %w", errSlice, err)
+	}
+	// Sliced files were asked for explicitly, they are never transformed.
+	if len(file.Ranges) == 0 {
+		o.transform(&file)
+	}
+	o.count(name, ext, int64(len(content)), file.Lines)
+	o.snapshot.Files = append(o.snapshot.Files, file)
+	return nil
+}
+
+// count adds the file name to the statistics.
+func (o *Output) count(name, language string, size int64, lines int) {
+	if o.counts == nil {
+		o.counts = make(map[string]fileCount)
+	}
+	o.counts[name] = fileCount{language: language, size: size, lines: lines}
+	o.snapshot.Stats.add(language, size, lines)
+}
+
+// ignore counts the path name as skipped by ignore patterns.
+func (o *Output) ignore(name string) {
+	if o.ignored == nil {
+		o.ignored = make(map[string]bool)
 	}
+	if !o.ignored[name] {
+		o.ignored[name] = true
+		o.snapshot.Stats.Ignored++
+	}
+}
 
-	ext := filepath.Ext(path)
+// fileLanguage returns the language of the file name, its extension.
+func fileLanguage(name string) string {
+	ext := filepath.Ext(name)
 	if ext != "" {
 		ext = ext[1:]
 	}
+	return ext
+}
 
-	fileContent := fmt.Sprintf("\n<a id=\"%s\"></a>\n# 📄 %s\n```%s\n%s\n```\n",
-		anchor, relPath, ext, string(content))
-	o.contents = append(o.contents, fileContent)
-	return nil
+// transform outlines and minifies the content of file, when enabled.
+func (o *Output) transform(file *File) {
+	if o.outline {
+		if summarizer, ok := lang.SummarizerFor(file.Path, o.summarizers); ok {
+			if outline, err := summarizer.Summarize(file.Path, file.Content); err != nil {
+				o.log.Warn("error outlining file, including it whole", "file", file.Path, "error", err)
+			} else {
+				file.Content, file.Outline = outline, true
+			}
+		}
+	}
+	if o.minify {
+		file.Content, file.Minified = lang.Minify(file.Path, file.Content)
+	}
+}
+
+func (o *Output) Snapshot() *Snapshot {
+	return &o.snapshot
 }
 
 func (o *Output) Generate() string {
-	return fmt.Sprintf("# Project Structure\n\n%s\n\n# Files Content\n%s",
-		strings.Join(o.structure, "\n"),
-		strings.Join(o.contents, "\n"))
+	return Markdown{}.renderSnapshot(&o.snapshot, "#", newSlugger())
 }
 
-func isBinaryFile(path string) (bool, error) {
-	file, err := os.Open(path)
+func readFile(ctx context.Context, fsys fs.FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer file.Close()
 
-	// Read first 512 bytes
-	buff := make([]byte, 512)
-	n, err := file.Read(buff)
-	if err != nil && err != io.EOF {
-		return false, err
-	}
+	return io.ReadAll(&contextReader{ctx: ctx, r: file})
+}
 
-	// Check if content contains non-printable characters
-	isbin := !utf8.Valid(buff[:n])
+// contextReader stops reading from r once ctx is done, so that reads from
+// slow file systems can be interrupted between chunks.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
 
-	if isbin {
-		return true, nil
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
 	}
-	return false, nil
+	return c.r.Read(p)
 }
 
-func createAnchor(path string) string {
-	anchor := strings.Map(func(r rune) rune {
-		if r == os.PathSeparator {
-			return '-'
-		}
-		return r
-	}, path)
-	anchor = strings.ReplaceAll(anchor, ".", "-")
-	anchor = strings.ToLower(strings.ReplaceAll(anchor, " ", "-"))
-
-	// Supprime tous les caractères non alphanumériques (sauf les tirets)
-	anchor = strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
-			return r
-		}
-		return -1
-	}, anchor)
-
-	// Convertit en minuscules
-	anchor = strings.ToLower(anchor)
-
-	// Remplace les tirets multiples par un seul tiret
-	for strings.Contains(anchor, "--") {
-		anchor = strings.ReplaceAll(anchor, "--", "-")
+func isBinaryFile(fsys fs.FS, name string) (bool, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return false, err
 	}
+	defer file.Close()
 
-	// Supprime les tirets au début et à la fin
-	anchor = strings.Trim(anchor, "-")
-
-	// Si l'ancre est vide après tout ça, utilise un fallback
-	if anchor == "" {
-		return "file"
+	// Read first 512 bytes
+	buff := make([]byte, 512)
+	n, err := file.Read(buff)
+	if err != nil && err != io.EOF {
+		return false, err
 	}
 
-	return anchor
+	// Check if content contains non-printable characters
+	return !utf8.Valid(buff[:n]), nil
 }
@@ -1,12 +1,13 @@
 package scanner
 
 import (
-	"os"
+	"context"
+	"errors"
 	"testing"
+	"testing/fstest"
 )
 
 func TestIsBinaryFile(t *testing.T) {
-	// Create temp files
 	tests := []struct {
 		name     string
 		content  []byte
@@ -36,21 +37,9 @@ func TestIsBinaryFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create temp file
-			tmpfile, err := os.CreateTemp("", "test")
-			if err != nil {
-				t.Fatal(err)
-			}
-			defer os.Remove(tmpfile.Name())
-
-			// Write content
-			if _, err := tmpfile.Write(tt.content); err != nil {
-				t.Fatal(err)
-			}
-			tmpfile.Close()
+			fsys := fstest.MapFS{"test": &fstest.MapFile{Data: tt.content}}
 
-			// Test the file
-			result, err := isBinaryFile(tmpfile.Name())
+			result, err := isBinaryFile(fsys, "test")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -60,3 +49,18 @@ func TestIsBinaryFile(t *testing.T) {
 		})
 	}
 }
+
+func TestReadFile_Canceled(t *testing.T) {
+	fsys := fstest.MapFS{"test": &fstest.MapFile{Data: []byte("content")}}
+
+	content, err := readFile(context.Background(), fsys, "test")
+	if err != nil || string(content) != "content" {
+		t.Fatalf("readFile() = %q, %v", content, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := readFile(ctx, fsys, "test"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
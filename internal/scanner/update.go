@@ -0,0 +1,228 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nouuu/gopeek/internal/ignore"
+)
+
+// Update refreshes the snapshot of a scanned root after the paths names
+// were created, modified or removed. Names are slash-separated and relative
+// to the root, "." standing for the whole tree. Only the changed paths are
+// read again, unless the root .gitignore changed and the whole tree has to
+// be scanned again. Update reports whether the snapshot changed.
+func (s *Scanner) Update(ctx context.Context, names []string) (bool, error) {
+	names = s.updatedTrees(names)
+	if len(names) == 0 {
+		return false, nil
+	}
+	if slices.Contains(names, ".") {
+		return true, s.rescan(ctx)
+	}
+
+	changed := false
+	for _, name := range names {
+		removed := s.removeTree(name)
+		before := len(s.output.snapshot.Entries) + len(s.output.snapshot.Errors)
+		if err := s.refresh(ctx, name); err != nil {
+			return changed, fmt.Errorf("error updating %s: %w", name, err)
+		}
+		added := len(s.output.snapshot.Entries) + len(s.output.snapshot.Errors) - before
+		changed = changed || removed > 0 || added > 0
+	}
+	if !changed {
+		return false, nil
+	}
+
+	s.output.recount()
+	if err := s.finish(ctx); err != nil {
+		return true, fmt.Errorf("error updating: %w", err)
+	}
+	return true, nil
+}
+
+// updatedTrees returns the roots of the trees to read again for the changed
+// paths names: changes below a collapsed directory update its summary, and
+// changes below paths that were not walked are dropped.
+func (s *Scanner) updatedTrees(names []string) []string {
+	entries := make(map[string]Entry, len(s.output.snapshot.Entries))
+	for _, entry := range s.output.snapshot.Entries {
+		entries[entry.Path] = entry
+	}
+
+	trees := make(map[string]bool)
+	for _, name := range names {
+		name = path.Clean(strings.TrimPrefix(name, "/"))
+		if name == "." || name == ".gitignore" {
+			return []string{"."}
+		}
+		// Look for the parents from the root, the first collapsed one holds
+		// the change.
+		tree, walked := name, true
+		parts := strings.Split(name, "/")
+		for i := 1; i < len(parts); i++ {
+			dir := strings.Join(parts[:i], "/")
+			entry, ok := entries[dir]
+			if !ok {
+				walked = false
+				break
+			}
+			if entry.Collapsed {
+				tree = dir
+				break
+			}
+		}
+		if walked {
+			trees[tree] = true
+		}
+	}
+
+	// A tree read again covers the changes below it.
+	var result []string
+	for tree := range trees {
+		covered := false
+		for dir := path.Dir(tree); dir != "." && !covered; dir = path.Dir(dir) {
+			covered = trees[dir]
+		}
+		if !covered {
+			result = append(result, tree)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// removeTree removes name and everything below it from the snapshot, and
+// returns the number of entries and errors removed.
+func (s *Scanner) removeTree(name string) int {
+	inTree := func(p string) bool {
+		return p == name || strings.HasPrefix(p, name+"/")
+	}
+	snapshot := &s.output.snapshot
+	removed := 0
+
+	entries := snapshot.Entries[:0]
+	for _, entry := range snapshot.Entries {
+		if inTree(entry.Path) {
+			removed++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	snapshot.Entries = entries
+
+	files := snapshot.Files[:0]
+	for _, file := range snapshot.Files {
+		if !inTree(file.Path) {
+			files = append(files, file)
+		}
+	}
+	snapshot.Files = files
+
+	errors := snapshot.Errors[:0]
+	for _, err := range snapshot.Errors {
+		rel, relErr := filepath.Rel(s.rootDir, err.Path)
+		if relErr == nil && inTree(filepath.ToSlash(rel)) {
+			removed++
+			continue
+		}
+		errors = append(errors, err)
+	}
+	snapshot.Errors = errors
+
+	for p := range s.output.counts {
+		if inTree(p) {
+			delete(s.output.counts, p)
+		}
+	}
+	for p := range s.output.ignored {
+		if inTree(p) {
+			delete(s.output.ignored, p)
+		}
+	}
+	for key := range s.parsed {
+		if inTree(key.name) {
+			delete(s.parsed, key)
+		}
+	}
+	return removed
+}
+
+// refresh adds name and everything below it to the snapshot, as the walk of
+// the root would have. Nothing is added when name no longer exists.
+func (s *Scanner) refresh(ctx context.Context, name string) error {
+	// Reading the parent directory gives the entry without following links,
+	// as in the walk.
+	siblings, err := fs.ReadDir(s.fsys, path.Dir(name))
+	if err != nil {
+		return nil
+	}
+	i := slices.IndexFunc(siblings, func(d fs.DirEntry) bool { return d.Name() == path.Base(name) })
+	if i < 0 {
+		return nil
+	}
+
+	if err := s.processPath(ctx, name, siblings[i], nil); err != nil && err != fs.SkipDir {
+		return err
+	}
+	return nil
+}
+
+// rescan scans the whole tree again, with the ignore rules reloaded.
+func (s *Scanner) rescan(ctx context.Context) error {
+	s.output.snapshot = Snapshot{Root: s.rootDir}
+	s.output.counts, s.output.ignored = nil, nil
+	s.parsed = nil
+	s.ignoreMatcher = ignore.NewMatcher()
+	for _, pattern := range s.config.IgnorePatterns {
+		s.ignoreMatcher.AddPattern(pattern)
+	}
+	s.loadGitignore()
+	return s.Scan(ctx)
+}
+
+// Clone returns a copy of the snapshot that later updates leave untouched.
+func (s *Snapshot) Clone() *Snapshot {
+	clone := *s
+	clone.Entries = slices.Clone(s.Entries)
+	clone.Files = slices.Clone(s.Files)
+	clone.Errors = slices.Clone(s.Errors)
+	clone.Packages = slices.Clone(s.Packages)
+	clone.Manifests = slices.Clone(s.Manifests)
+	clone.Stats.Languages = maps.Clone(s.Stats.Languages)
+	return &clone
+}
+
+// recount computes the statistics of the snapshot again from what the files
+// and errors recorded.
+func (o *Output) recount() {
+	stats := Stats{Elapsed: o.snapshot.Stats.Elapsed, Ignored: len(o.ignored)}
+	for _, count := range o.counts {
+		stats.add(count.language, count.size, count.lines)
+	}
+	for _, err := range o.snapshot.Errors {
+		switch err.Kind {
+		case ErrorBinary:
+			stats.Binary++
+		case ErrorTooLarge:
+			stats.TooLarge++
+		default:
+			stats.Errors++
+		}
+	}
+	o.snapshot.Stats = stats
+}
+
+// Ignores reports whether the path name, slash-separated and relative to the
+// root, is left out of the scan by the ignore patterns or as the output.
+func (s *Scanner) Ignores(name string) bool {
+	return s.shouldIgnore(filepath.Join(s.rootDir, filepath.FromSlash(name)))
+}
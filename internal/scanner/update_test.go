@@ -0,0 +1,224 @@
+package scanner
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nouuu/gopeek/internal/logger"
+)
+
+func TestScanner_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  func(*Config)
+		change  func(fstest.MapFS)
+		names   []string
+		changed bool
+	}{
+		{
+			name:    "Modified file",
+			change:  func(fsys fstest.MapFS) { fsys["main.go"] = file("package main\n\nfunc main() {}\n") },
+			names:   []string{"main.go"},
+			changed: true,
+		},
+		{
+			name:    "Created file",
+			change:  func(fsys fstest.MapFS) { fsys["internal/one/two.go"] = file("package one\n") },
+			names:   []string{"internal/one/two.go"},
+			changed: true,
+		},
+		{
+			name:    "Removed file",
+			change:  func(fsys fstest.MapFS) { delete(fsys, "internal/one/one.go") },
+			names:   []string{"internal/one/one.go"},
+			changed: true,
+		},
+		{
+			name: "Created directory",
+			change: func(fsys fstest.MapFS) {
+				fsys["pkg/api/api.go"] = file("package api\n")
+				fsys["pkg/api/api_test.go"] = file("package api\n")
+			},
+			names:   []string{"pkg", "pkg/api"},
+			changed: true,
+		},
+		{
+			name: "Removed directory",
+			change: func(fsys fstest.MapFS) {
+				delete(fsys, "internal/one")
+				delete(fsys, "internal/one/one.go")
+				delete(fsys, "internal/two/two.go")
+			},
+			names:   []string{"internal"},
+			changed: true,
+		},
+		{
+			name:    "Binary file",
+			change:  func(fsys fstest.MapFS) { fsys["logo.png"] = file("\x89PNG\x00\x00") },
+			names:   []string{"logo.png"},
+			changed: true,
+		},
+		{
+			name:    "Ignored file",
+			change:  func(fsys fstest.MapFS) { fsys["debug.log"] = file("log") },
+			names:   []string{"debug.log"},
+			changed: false,
+		},
+		{
+			name:    "Unknown path",
+			names:   []string{"missing/file.go", "gone.go"},
+			changed: false,
+		},
+		{
+			name:    "Change in a collapsed directory",
+			config:  func(config *Config) { config.CollapseThreshold = 2 },
+			change:  func(fsys fstest.MapFS) { fsys["fixtures/c/e.json"] = file("{\"e\": 1}") },
+			names:   []string{"fixtures/c/e.json"},
+			changed: true,
+		},
+		{
+			name:    "Gitignore",
+			change:  func(fsys fstest.MapFS) { fsys[".gitignore"] = file("fixtures/\n") },
+			names:   []string{".gitignore"},
+			changed: true,
+		},
+		{
+			name:   "Overview and dependencies",
+			config: func(config *Config) { config.Overview, config.Dependencies = true, true },
+			change: func(fsys fstest.MapFS) {
+				fsys["main.go"] = file("package main\n\nimport _ \"example.com/app/internal/one\"\n")
+			},
+			names:   []string{"main.go"},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"go.mod":              file("module example.com/app\n\ngo 1.22\n"),
+				"main.go":             file("package main\n"),
+				"fixtures/a.json":     file("{}"),
+				"fixtures/b.json":     file("{}"),
+				"fixtures/c/d.json":   file("{}"),
+				"internal/one":        &fstest.MapFile{Mode: fs.ModeDir},
+				"internal/one/one.go": file("package one\n"),
+				"internal/two/two.go": file("package two\n"),
+			}
+			config := DefaultConfig()
+			config.IgnorePatterns = append(config.IgnorePatterns, "*.log")
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			scanner := NewFS("project", fsys, config, logger.Default())
+			if err := scanner.Scan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			kept := scanner.Snapshot().Clone()
+			if tt.change != nil {
+				tt.change(fsys)
+			}
+			changed, err := scanner.Update(context.Background(), tt.names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Copies are left untouched by the update.
+			files := 0
+			for _, language := range kept.Stats.Languages {
+				files += language.Files
+			}
+			if files != kept.Stats.Files {
+				t.Errorf("Kept snapshot counts %d files by language, want %d", files, kept.Stats.Files)
+			}
+			if changed != tt.changed {
+				t.Errorf("Update() = %v, want %v", changed, tt.changed)
+			}
+
+			// The updated snapshot matches a new scan of the tree.
+			fresh := NewFS("project", fsys, config, logger.Default())
+			if err := fresh.Scan(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			got, want := *scanner.Snapshot(), *fresh.Snapshot()
+			got.Stats.Elapsed, want.Stats.Elapsed = 0, 0
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Updated snapshot = %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestScanner_UpdateReadsChangedFiles(t *testing.T) {
+	fsys := &openCounter{FS: fstest.MapFS{
+		"go.mod":              file("module example.com/app\n\ngo 1.22\n"),
+		"main.go":             file("package main\n"),
+		"internal/one/one.go": file("package one\n"),
+	}, opens: make(map[string]int)}
+	config := DefaultConfig()
+	config.Overview, config.Dependencies = true, true
+	scanner := NewFS("project", fsys, config, logger.Default())
+	if err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	before := maps.Clone(fsys.opens)
+	fsys.FS.(fstest.MapFS)["main.go"] = file("package main\n\nimport _ \"example.com/app/internal/one\"\n")
+	if _, err := scanner.Update(context.Background(), []string{"main.go"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"go.mod", "internal/one/one.go"} {
+		if fsys.opens[name] != before[name] {
+			t.Errorf("Update() opened the unchanged %s %d times", name, fsys.opens[name]-before[name])
+		}
+	}
+	if fsys.opens["main.go"] == before["main.go"] {
+		t.Error("Update() did not read the changed main.go")
+	}
+	want := []string{"example.com/app/internal/one"}
+	if packages := scanner.Snapshot().Packages; len(packages) != 2 || !reflect.DeepEqual(packages[0].Imports, want) {
+		t.Errorf("Packages = %+v, want example.com/app importing %v", packages, want)
+	}
+}
+
+// openCounter counts the files opened through it, by name.
+type openCounter struct {
+	fs.FS
+	opens map[string]int
+}
+
+func (c *openCounter) Open(name string) (fs.File, error) {
+	c.opens[name]++
+	return c.FS.Open(name)
+}
+
+func TestScanner_Ignores(t *testing.T) {
+	config := DefaultConfig()
+	config.Output = "project/docs/out.md"
+	scanner := NewFS("project", fstest.MapFS{}, config, logger.Default())
+
+	tests := map[string]bool{
+		"main.go":                  false,
+		"node_modules":             true,
+		"docs/out.md":              true,
+		"docs/out.part2.md":        true,
+		"docs/.out.md.tmp-123":     true,
+		"docs/.out.part1.md.tmp-4": true,
+		"docs/out.partial.md":      false,
+		"docs/out.part.md":         false,
+		"out.part1.md":             false,
+	}
+	for name, want := range tests {
+		if got := scanner.Ignores(name); got != want {
+			t.Errorf("Ignores(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WriteOutput atomically writes a generated document to path: data goes to a
+// temporary file in the same directory which is then renamed over path, so
+// readers never see a truncated document. Permissions of an existing file
+// are preserved, new files get the default ones with the umask applied. A
+// symbolic link at path is kept and the file it points to is replaced.
+func WriteOutput(path string, data []byte) (err error) {
+	if resolved, evalErr := filepath.EvalSymlinks(path); evalErr == nil {
+		path = resolved
+	}
+	var perm fs.FileMode
+	if info, statErr := os.Stat(path); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := createTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("error creating temporary output file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("error syncing output: %w", err)
+	}
+	if perm != 0 {
+		if err = tmp.Chmod(perm); err != nil {
+			return fmt.Errorf("error setting output permissions: %w", err)
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error closing output: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error moving output into place: %w", err)
+	}
+	return nil
+}
+
+// createTemp creates a new file in dir whose name starts with prefix. Unlike
+// os.CreateTemp, the file gets the permissions os.Create would give it.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for range 100 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+	return nil, fmt.Errorf("no unused name for %s*", filepath.Join(dir, prefix))
+}
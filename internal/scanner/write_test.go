@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWriteOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "scanner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// New files get the permissions os.Create gives, with the umask applied.
+	reference := filepath.Join(t.TempDir(), "reference")
+	if err := os.WriteFile(reference, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createPerm := info.Mode().Perm()
+
+	tests := []struct {
+		name       string
+		existing   []byte
+		perm       os.FileMode
+		link       bool // path is a symbolic link to the existing file
+		expectPerm os.FileMode
+	}{
+		{
+			name:       "New file",
+			expectPerm: createPerm,
+		},
+		{
+			name:       "Replace existing file",
+			existing:   []byte("previous content that is longer than the new one"),
+			perm:       0o600,
+			expectPerm: 0o600,
+		},
+		{
+			name:       "Symlinked file",
+			existing:   []byte("previous content"),
+			perm:       0o640,
+			link:       true,
+			expectPerm: 0o640,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.name+".md")
+			target := path
+			if tt.link {
+				target = filepath.Join(tmpDir, tt.name+" target.md")
+				if err := os.Symlink(filepath.Base(target), path); err != nil {
+					t.Skip("symlinks not supported:", err)
+				}
+			}
+			if tt.existing != nil {
+				if err := os.WriteFile(target, tt.existing, tt.perm); err != nil {
+					t.Fatal(err)
+				}
+				// WriteFile leaves the permissions of existing files alone.
+				if err := os.Chmod(target, tt.perm); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := WriteOutput(path, []byte("new content")); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "new content" {
+				t.Errorf("Content = %q, want %q", content, "new content")
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != tt.expectPerm {
+				t.Errorf("Permissions = %v, want %v", info.Mode().Perm(), tt.expectPerm)
+			}
+			if tt.link {
+				if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+					t.Errorf("Expected %s to stay a symbolic link", path)
+				}
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("Expected no leftover temporary files, got %s", entry.Name())
+		}
+	}
+}
+
+func TestWriteOutput_Error(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "gopeek-missing-dir", "output.md")
+	if err := WriteOutput(path, []byte("content")); err == nil {
+		t.Error("Expected error when the output directory does not exist")
+	}
+}
@@ -0,0 +1,126 @@
+// Package watch reports the paths that change in a directory tree, in
+// batches gathered until the tree stays quiet for a while.
+package watch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watcher watches the directories of a tree. It uses inotify on Linux and
+// polls the tree on other systems.
+type Watcher struct {
+	root    string
+	skip    func(name string) bool
+	changes chan []string
+	errors  chan error
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	notifier
+}
+
+// New watches the tree at root. skip reports the paths, slash-separated and
+// relative to root, whose changes do not matter: skipped directories are not
+// watched. Changes are batched until none happened for debounce.
+func New(root string, debounce time.Duration, skip func(name string) bool) (*Watcher, error) {
+	w := &Watcher{
+		root:    root,
+		skip:    skip,
+		changes: make(chan []string),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+	}
+	events := make(chan string)
+	if err := w.open(events); err != nil {
+		return nil, err
+	}
+	w.wg.Add(1)
+	go w.batch(events, debounce)
+	return w, nil
+}
+
+// Changes returns the batches of changed paths, slash-separated and relative
+// to the root, "." standing for the whole tree when changes were lost. It is
+// closed when the watcher is.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Errors returns the errors that stop the watch of part of the tree. They
+// must be received for the watch to go on.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Add watches the directory name and the directories below it again, after
+// what skip reports about them changed.
+func (w *Watcher) Add(name string) error {
+	return w.add(name)
+}
+
+// Close stops the watch.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.close()
+		w.wg.Wait()
+	})
+	return err
+}
+
+// batch gathers the changed paths from events, and sends them once no path
+// changed for debounce.
+func (w *Watcher) batch(events <-chan string, debounce time.Duration) {
+	defer w.wg.Done()
+	defer close(w.changes)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case name := <-events:
+			pending[name] = true
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			pending = make(map[string]bool)
+			select {
+			case w.changes <- names:
+			case <-w.done:
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// send reports the change of the path name.
+func (w *Watcher) send(events chan<- string, name string) {
+	select {
+	case events <- name:
+	case <-w.done:
+	}
+}
+
+// fail reports an error of the watch.
+func (w *Watcher) fail(err error) {
+	select {
+	case w.errors <- err:
+	case <-w.done:
+	}
+}
@@ -0,0 +1,164 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ONLYDIR | syscall.IN_DONT_FOLLOW
+
+// notifier holds the inotify instance watching every directory of the tree.
+type notifier struct {
+	file *os.File
+	fd   int
+
+	mu   sync.Mutex
+	dirs map[int]string // watch descriptors to the directories they watch
+	wds  map[string]int
+}
+
+func (w *Watcher) open(events chan<- string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("error starting the watch: %w", os.NewSyscallError("inotify_init1", err))
+	}
+	// Reading through an os.File waits in the runtime poller, and closing
+	// the file stops the read.
+	w.fd, w.file = fd, os.NewFile(uintptr(fd), "inotify")
+	w.dirs, w.wds = make(map[int]string), make(map[string]int)
+	if err := w.add("."); err != nil {
+		w.file.Close()
+		return err
+	}
+	w.wg.Add(1)
+	go w.read(events)
+	return nil
+}
+
+func (w *Watcher) close() error {
+	return w.file.Close()
+}
+
+// add watches the directory name and the directories below it, except the
+// skipped ones.
+func (w *Watcher) add(name string) error {
+	start := filepath.Join(w.root, filepath.FromSlash(name))
+	return filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories removed in the meantime are reported on their own.
+			if p == start && (name == "." || !errors.Is(err, fs.ErrNotExist)) {
+				return fmt.Errorf("error watching %s: %w", p, err)
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(w.root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && w.skip(rel) {
+			return fs.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, p, watchMask)
+		switch {
+		case errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENOTDIR):
+			return fs.SkipDir
+		case errors.Is(err, syscall.ENOSPC):
+			return fmt.Errorf("error watching %s: too many directories, raise fs.inotify.max_user_watches", p)
+		case err != nil:
+			return fmt.Errorf("error watching %s: %w", p, os.NewSyscallError("inotify_add_watch", err))
+		}
+		w.mu.Lock()
+		w.dirs[wd], w.wds[rel] = rel, wd
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+// remove stops watching the directory name and the directories below it.
+func (w *Watcher) remove(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir, wd := range w.wds {
+		if dir == name || strings.HasPrefix(dir, name+"/") {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, dir)
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+// read reports the events of the inotify instance until it is closed.
+func (w *Watcher) read(events chan<- string) {
+	defer w.wg.Done()
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.fail(fmt.Errorf("error reading changes: %w", err))
+			}
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			offset = start + int(event.Len)
+			name := strings.TrimRight(string(buf[start:offset]), "\x00")
+			w.handle(events, int(event.Wd), event.Mask, name)
+		}
+	}
+}
+
+func (w *Watcher) handle(events chan<- string, wd int, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Events were lost, directories created meanwhile are not watched.
+		if err := w.add("."); err != nil {
+			w.fail(err)
+		}
+		w.send(events, ".")
+		return
+	}
+
+	w.mu.Lock()
+	dir, ok := w.dirs[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+		if w.wds[dir] == wd {
+			delete(w.wds, dir)
+		}
+	}
+	w.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+
+	rel := path.Join(dir, name)
+	if w.skip(rel) {
+		return
+	}
+	if mask&syscall.IN_ISDIR != 0 {
+		switch {
+		case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			if err := w.add(rel); err != nil {
+				w.fail(err)
+			}
+		case mask&syscall.IN_MOVED_FROM != 0:
+			w.remove(rel)
+		}
+	}
+	w.send(events, rel)
+}
@@ -0,0 +1,103 @@
+//go:build !linux
+
+package watch
+
+import (
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// pollInterval is how often the tree is walked to find changes.
+const pollInterval = time.Second
+
+type pathState struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// notifier polls the tree, comparing the size, time and mode of its paths
+// with the previous walk.
+type notifier struct {
+	state map[string]pathState
+}
+
+func (w *Watcher) open(events chan<- string) error {
+	state, err := w.walk()
+	if err != nil {
+		return err
+	}
+	w.state = state
+	w.wg.Add(1)
+	go w.poll(events)
+	return nil
+}
+
+func (w *Watcher) close() error {
+	return nil
+}
+
+// add has nothing to do, every walk asks skip again.
+func (w *Watcher) add(name string) error {
+	return nil
+}
+
+func (w *Watcher) poll(events chan<- string) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.done:
+			return
+		}
+
+		state, err := w.walk()
+		if err != nil {
+			w.fail(err)
+			continue
+		}
+		for name, current := range state {
+			if previous, ok := w.state[name]; !ok || previous != current {
+				w.send(events, name)
+			}
+		}
+		for name := range w.state {
+			if _, ok := state[name]; !ok {
+				w.send(events, name)
+			}
+		}
+		w.state = state
+	}
+}
+
+// walk records the state of the paths of the tree, except the skipped ones.
+func (w *Watcher) walk() (map[string]pathState, error) {
+	state := make(map[string]pathState)
+	err := filepath.WalkDir(w.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == w.root {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(w.root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if w.skip(rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			state[rel] = pathState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		}
+		return nil
+	})
+	return state, err
+}
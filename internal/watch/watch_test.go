@@ -0,0 +1,102 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"sub", "ignored"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	skip := func(name string) bool { return name == "ignored" || strings.HasPrefix(name, "ignored/") }
+	w, err := New(root, 50*time.Millisecond, skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	write(t, root, "sub/a.txt", "a")
+	write(t, root, "ignored/b.txt", "b")
+	if err := os.Mkdir(filepath.Join(root, "new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first batch may arrive before all the changes are seen.
+	changed := make(map[string]bool)
+	deadline := time.After(5 * time.Second)
+	for !changed["sub/a.txt"] || !changed["new"] {
+		select {
+		case names := <-w.Changes():
+			for _, name := range names {
+				changed[name] = true
+			}
+		case err := <-w.Errors():
+			t.Fatal(err)
+		case <-deadline:
+			t.Fatalf("Changes = %v, want sub/a.txt and new", changed)
+		}
+	}
+	if changed["ignored/b.txt"] {
+		t.Errorf("Expected changes of skipped directories to be left out, got %v", changed)
+	}
+
+	// Directories created after the start are watched too.
+	write(t, root, "new/c.txt", "c")
+	for !changed["new/c.txt"] {
+		select {
+		case names := <-w.Changes():
+			for _, name := range names {
+				changed[name] = true
+			}
+		case err := <-w.Errors():
+			t.Fatal(err)
+		case <-deadline:
+			t.Fatalf("Changes = %v, want new/c.txt", changed)
+		}
+	}
+}
+
+func TestWatcher_Batch(t *testing.T) {
+	root := t.TempDir()
+	w, err := New(root, 200*time.Millisecond, func(string) bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, root, "a.txt", "a")
+	write(t, root, "b.txt", "b")
+	write(t, root, "a.txt", "aa")
+
+	select {
+	case names := <-w.Changes():
+		if !slices.Equal(names, []string{"a.txt", "b.txt"}) {
+			t.Errorf("Changes = %v, want [a.txt b.txt] in one batch", names)
+		}
+	case err := <-w.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a batch of changes")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-w.Changes(); ok {
+		t.Error("Expected Changes to be closed with the watcher")
+	}
+}
+
+func write(t *testing.T, root, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}